@@ -0,0 +1,253 @@
+// 从配置文件和环境变量加载连接池配置
+//
+// 配置文件为 JSON 或 YAML 格式，根据扩展名选择解析方式（.json / .yaml / .yml），时长使用 "5s"、"100ms" 这样的字符串，例如：
+//
+//	name: greeter
+//	target: localhost:50051
+//	maxConns: 30
+//	maxIdleConns: 5
+//	maxRefs: 10
+//	closeWait: 20s
+//	dial:
+//	  tls:
+//	    caFile: /etc/certs/ca.pem
+//	  keepalive:
+//	    time: 30s
+//
+// 环境变量按照 <PREFIX>_<字段> 的形式覆盖配置文件中的值，嵌套字段以下划线连接，例如：
+//
+//	GREETER_MAX_CONNS=50
+//	GREETER_DIAL_TLS_CA_FILE=/etc/certs/ca.pem
+//	GREETER_DIAL_KEEPALIVE_TIME=1m
+//
+// Config 带有 json、yaml 标签，也可以嵌入到应用自身的配置结构中使用
+package config
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported config file format")
+
+// 连接池配置
+type Config struct {
+	Name             string   `json:"name" yaml:"name" env:"NAME"`
+	ExpvarName       string   `json:"expvarName" yaml:"expvarName" env:"EXPVAR_NAME"`
+	Target           string   `json:"target" yaml:"target" env:"TARGET"`
+	Debug            bool     `json:"debug" yaml:"debug" env:"DEBUG"`
+	DescribeDuration Duration `json:"describeDuration" yaml:"describeDuration" env:"DESCRIBE_DURATION"`
+	CheckPeriod      Duration `json:"checkPeriod" yaml:"checkPeriod" env:"CHECK_PERIOD"`
+	CloseWait        Duration `json:"closeWait" yaml:"closeWait" env:"CLOSE_WAIT"`
+	ConnTimeOut      Duration `json:"connTimeOut" yaml:"connTimeOut" env:"CONN_TIMEOUT"`
+	ConnBlock        bool     `json:"connBlock" yaml:"connBlock" env:"CONN_BLOCK"`
+	ReconnectIdle    bool     `json:"reconnectIdle" yaml:"reconnectIdle" env:"RECONNECT_IDLE"`
+	MinConns         int32    `json:"minConns" yaml:"minConns" env:"MIN_CONNS"`
+	MaxConns         int32    `json:"maxConns" yaml:"maxConns" env:"MAX_CONNS"`
+	MaxIdleConns     int32    `json:"maxIdleConns" yaml:"maxIdleConns" env:"MAX_IDLE_CONNS"`
+	MaxRefs          int32    `json:"maxRefs" yaml:"maxRefs" env:"MAX_REFS"`
+	NewConnRate      int32    `json:"newConnRate" yaml:"newConnRate" env:"NEW_CONN_RATE"`
+
+	Locality Locality `json:"locality" yaml:"locality" env:"LOCALITY"`
+	Dial     Dial     `json:"dial" yaml:"dial" env:"DIAL"`
+}
+
+// 调用方所在的位置
+type Locality struct {
+	Region string `json:"region" yaml:"region" env:"REGION"`
+	Zone   string `json:"zone" yaml:"zone" env:"ZONE"`
+}
+
+// 常用的拨号配置
+type Dial struct {
+	Insecure       bool       `json:"insecure" yaml:"insecure" env:"INSECURE"` // 不使用传输层加密
+	Authority      string     `json:"authority" yaml:"authority" env:"AUTHORITY"`
+	MaxRecvMsgSize int        `json:"maxRecvMsgSize" yaml:"maxRecvMsgSize" env:"MAX_RECV_MSG_SIZE"`
+	MaxSendMsgSize int        `json:"maxSendMsgSize" yaml:"maxSendMsgSize" env:"MAX_SEND_MSG_SIZE"`
+	TLS            *TLS       `json:"tls" yaml:"tls" env:"TLS"`
+	Keepalive      *Keepalive `json:"keepalive" yaml:"keepalive" env:"KEEPALIVE"`
+}
+
+// TLS 配置
+type TLS struct {
+	CAFile             string `json:"caFile" yaml:"caFile" env:"CA_FILE"`
+	CertFile           string `json:"certFile" yaml:"certFile" env:"CERT_FILE"`
+	KeyFile            string `json:"keyFile" yaml:"keyFile" env:"KEY_FILE"`
+	ServerName         string `json:"serverName" yaml:"serverName" env:"SERVER_NAME"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify" yaml:"insecureSkipVerify" env:"INSECURE_SKIP_VERIFY"`
+}
+
+// 客户端 keepalive 配置
+type Keepalive struct {
+	Time                Duration `json:"time" yaml:"time" env:"TIME"`
+	Timeout             Duration `json:"timeout" yaml:"timeout" env:"TIMEOUT"`
+	PermitWithoutStream bool     `json:"permitWithoutStream" yaml:"permitWithoutStream" env:"PERMIT_WITHOUT_STREAM"`
+}
+
+// 从文件加载配置，再用环境变量覆盖
+// 1. path 为空时只从环境变量加载
+// 2. envPrefix 为空时不读取环境变量
+func Load(path string, envPrefix string) (Config, error) {
+	c := Config{}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
+
+		format := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if err := Decode(data, format, &c); err != nil {
+			return c, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+
+	if envPrefix != "" {
+		if err := c.ApplyEnv(envPrefix, os.LookupEnv); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// 解析配置，format 为 json、yaml 或 yml，未知的字段视为错误
+func Decode(data []byte, format string, c *Config) error {
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(c)
+	case "yaml", "yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		return dec.Decode(c)
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// 转换为连接池配置
+// 1. 根据 Dial 生成 grpc 拨号选项，TLS 优先于 Insecure
+// 2. 未设置的字段保持零值，由 NewPool 校验并应用默认值，必填字段见 Options.Validate
+func (c Config) Options() (gogrpcpool.Options, error) {
+	dopts, err := c.Dial.options()
+	if err != nil {
+		return gogrpcpool.Options{}, err
+	}
+
+	return gogrpcpool.Options{
+		Name:             c.Name,
+		ExpvarName:       c.ExpvarName,
+		Debug:            c.Debug,
+		DescribeDuration: time.Duration(c.DescribeDuration),
+		CheckPeriod:      time.Duration(c.CheckPeriod),
+		CloseWait:        time.Duration(c.CloseWait),
+		ConnTimeOut:      time.Duration(c.ConnTimeOut),
+		ConnBlock:        c.ConnBlock,
+		ReconnectIdle:    c.ReconnectIdle,
+		Target:           c.Target,
+		Dopts:            dopts,
+		MinConns:         c.MinConns,
+		MaxConns:         c.MaxConns,
+		MaxIdleConns:     c.MaxIdleConns,
+		MaxRefs:          c.MaxRefs,
+		NewConnRate:      c.NewConnRate,
+		Locality:         gogrpcpool.Locality{Region: c.Locality.Region, Zone: c.Locality.Zone},
+	}, nil
+}
+
+// 按照配置实例化连接池，opts 用于设置无法通过配置文件表达的选项，例如端点发现、日志等
+func (c Config) NewPool(opts ...gogrpcpool.Option) (*gogrpcpool.Pool, error) {
+	o, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	o.Apply(opts...)
+	return gogrpcpool.NewPool(o)
+}
+
+// 生成 grpc 拨号选项
+func (d Dial) options() ([]grpc.DialOption, error) {
+	dopts := []grpc.DialOption{}
+
+	switch {
+	case d.TLS != nil:
+		cfg, err := d.TLS.config()
+		if err != nil {
+			return nil, err
+		}
+		dopts = append(dopts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	case d.Insecure:
+		dopts = append(dopts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+
+	if d.Authority != "" {
+		dopts = append(dopts, grpc.WithAuthority(d.Authority))
+	}
+
+	callOpts := []grpc.CallOption{}
+	if d.MaxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(d.MaxRecvMsgSize))
+	}
+	if d.MaxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(d.MaxSendMsgSize))
+	}
+	if len(callOpts) > 0 {
+		dopts = append(dopts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if d.Keepalive != nil {
+		dopts = append(dopts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(d.Keepalive.Time),
+			Timeout:             time.Duration(d.Keepalive.Timeout),
+			PermitWithoutStream: d.Keepalive.PermitWithoutStream,
+		}))
+	}
+	return dopts, nil
+}
+
+// 生成 tls 配置
+func (t TLS) config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read tls ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("read tls ca file %s: no certificates found", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load tls key pair: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,298 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+)
+
+const yamlConfig = `
+name: greeter
+target: localhost:50051
+debug: true
+closeWait: 20s
+connTimeOut: 500ms
+minConns: 1
+maxConns: 30
+maxIdleConns: 5
+maxRefs: 10
+locality:
+  zone: zone-a
+dial:
+  authority: greeter.local
+  tls:
+    serverName: greeter
+  keepalive:
+    time: 30s
+    permitWithoutStream: true
+`
+
+const jsonConfig = `{
+	"name": "greeter",
+	"target": "localhost:50051",
+	"debug": true,
+	"closeWait": "20s",
+	"connTimeOut": 500000000,
+	"minConns": 1,
+	"maxConns": 30,
+	"maxIdleConns": 5,
+	"maxRefs": 10,
+	"locality": {"zone": "zone-a"},
+	"dial": {
+		"authority": "greeter.local",
+		"tls": {"serverName": "greeter"},
+		"keepalive": {"time": "30s", "permitWithoutStream": true}
+	}
+}`
+
+// yamlConfig 和 jsonConfig 解析后的配置
+var decoded = Config{
+	Name:         "greeter",
+	Target:       "localhost:50051",
+	Debug:        true,
+	CloseWait:    Duration(20 * time.Second),
+	ConnTimeOut:  Duration(500 * time.Millisecond),
+	MinConns:     1,
+	MaxConns:     30,
+	MaxIdleConns: 5,
+	MaxRefs:      10,
+	Locality:     Locality{Zone: "zone-a"},
+	Dial: Dial{
+		Authority: "greeter.local",
+		TLS:       &TLS{ServerName: "greeter"},
+		Keepalive: &Keepalive{Time: Duration(30 * time.Second), PermitWithoutStream: true},
+	},
+}
+
+func TestDecode(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		data   string
+		want   Config
+		err    string
+	}{
+		{name: "yaml", format: "yaml", data: yamlConfig, want: decoded},
+		{name: "yml", format: "yml", data: yamlConfig, want: decoded},
+		{name: "json", format: "json", data: jsonConfig, want: decoded},
+		{name: "unknown field yaml", format: "yaml", data: "maxConn: 10\n", err: "field maxConn not found"},
+		{name: "unknown field json", format: "json", data: `{"maxConn": 10}`, err: `unknown field "maxConn"`},
+		{name: "invalid duration", format: "yaml", data: "closeWait: soon\n", err: "invalid duration"},
+		{name: "unsupported format", format: "toml", data: "", err: ErrUnsupportedFormat.Error()},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := Config{}
+			err := Decode([]byte(c.data), c.format, &got)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("Decode = %v, want error containing %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("Decode = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	cases := []struct {
+		name string
+		base Config
+		env  map[string]string
+		want Config
+		err  string
+	}{
+		{
+			name: "no env",
+			base: Config{MaxConns: 10},
+			want: Config{MaxConns: 10},
+		},
+		{
+			name: "scalars",
+			base: Config{MaxConns: 10, Name: "greeter"},
+			env: map[string]string{
+				"APP_MAX_CONNS":     "50",
+				"APP_TARGET":        "localhost:50052",
+				"APP_DEBUG":         "true",
+				"APP_CLOSE_WAIT":    "1m",
+				"APP_LOCALITY_ZONE": "zone-b",
+			},
+			want: Config{
+				Name:      "greeter",
+				MaxConns:  50,
+				Target:    "localhost:50052",
+				Debug:     true,
+				CloseWait: Duration(time.Minute),
+				Locality:  Locality{Zone: "zone-b"},
+			},
+		},
+		{
+			name: "nested pointer created on demand",
+			env: map[string]string{
+				"APP_DIAL_TLS_CA_FILE":       "/etc/certs/ca.pem",
+				"APP_DIAL_MAX_RECV_MSG_SIZE": "1024",
+				"APP_DIAL_KEEPALIVE_TIMEOUT": "5s",
+			},
+			want: Config{Dial: Dial{
+				MaxRecvMsgSize: 1024,
+				TLS:            &TLS{CAFile: "/etc/certs/ca.pem"},
+				Keepalive:      &Keepalive{Timeout: Duration(5 * time.Second)},
+			}},
+		},
+		{
+			name: "nested pointer merged with file",
+			base: Config{Dial: Dial{TLS: &TLS{ServerName: "greeter"}}},
+			env:  map[string]string{"APP_DIAL_TLS_CA_FILE": "/etc/certs/ca.pem"},
+			want: Config{Dial: Dial{TLS: &TLS{ServerName: "greeter", CAFile: "/etc/certs/ca.pem"}}},
+		},
+		{
+			name: "invalid int",
+			env:  map[string]string{"APP_MAX_CONNS": "many"},
+			err:  "env APP_MAX_CONNS",
+		},
+		{
+			name: "int overflow",
+			env:  map[string]string{"APP_MAX_CONNS": "4294967296"},
+			err:  "env APP_MAX_CONNS",
+		},
+		{
+			name: "invalid duration",
+			env:  map[string]string{"APP_DIAL_KEEPALIVE_TIME": "30"},
+			err:  "env APP_DIAL_KEEPALIVE_TIME",
+		},
+		{
+			name: "invalid bool",
+			env:  map[string]string{"APP_DEBUG": "yes please"},
+			err:  "env APP_DEBUG",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lookup := func(name string) (string, bool) {
+				v, ok := c.env[name]
+				return v, ok
+			}
+
+			got := c.base
+			err := got.ApplyEnv("APP", lookup)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("ApplyEnv = %v, want error containing %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("ApplyEnv = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.yaml")
+	if err := os.WriteFile(path, []byte(yamlConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("GREETER_MAX_CONNS", "50")
+	t.Setenv("GREETER_DIAL_TLS_CA_FILE", "/etc/certs/ca.pem")
+
+	c, err := Load(path, "GREETER")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := decoded
+	want.MaxConns = 50
+	want.Dial.TLS = &TLS{ServerName: "greeter", CAFile: "/etc/certs/ca.pem"}
+	if !reflect.DeepEqual(c, want) {
+		t.Fatalf("Load = %+v, want %+v", c, want)
+	}
+
+	// 未设置前缀时不读取环境变量
+	if c, err := Load(path, ""); err != nil || c.MaxConns != 30 {
+		t.Fatalf("Load without prefix = %d, %v, want 30", c.MaxConns, err)
+	}
+}
+
+// 生成一个自签名的 CA 证书文件
+func writeCA(t *testing.T) string {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigOptions(t *testing.T) {
+	c := decoded
+	c.Dial.TLS = &TLS{CAFile: writeCA(t), ServerName: "greeter"}
+
+	o, err := c.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Name != "greeter" || o.Target != "localhost:50051" || !o.Debug || o.MaxConns != 30 ||
+		o.MaxIdleConns != 5 || o.MaxRefs != 10 || o.MinConns != 1 ||
+		o.CloseWait != 20*time.Second || o.ConnTimeOut != 500*time.Millisecond ||
+		o.Locality != (gogrpcpool.Locality{Zone: "zone-a"}) {
+		t.Fatalf("Options = %+v", o)
+	}
+
+	// tls、authority、keepalive 各生成一个拨号选项
+	if len(o.Dopts) != 3 {
+		t.Fatalf("got %d dial options, want 3", len(o.Dopts))
+	}
+
+	// CA 文件中没有证书
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c.Dial.TLS = &TLS{CAFile: empty}
+	if _, err := c.Options(); err == nil || !strings.Contains(err.Error(), "no certificates found") {
+		t.Fatalf("Options with empty CA file = %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// 用环境变量覆盖配置
+// 1. 环境变量名为 <prefix>_<env 标签>，嵌套结构的 env 标签以下划线连接
+// 2. 指针类型的嵌套结构在有对应的环境变量时才会被创建
+// 3. lookup 通常为 os.LookupEnv
+func (c *Config) ApplyEnv(prefix string, lookup func(string) (string, bool)) error {
+	_, err := applyEnv(reflect.ValueOf(c).Elem(), prefix, lookup)
+	return err
+}
+
+var durationType = reflect.TypeOf(Duration(0))
+
+// 递归设置结构体字段，返回是否设置了任何字段
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) (bool, error) {
+	set := false
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+
+		name := prefix + "_" + tag
+		fv := v.Field(i)
+
+		switch {
+		case field.Type.Kind() == reflect.Struct:
+			ok, err := applyEnv(fv, name, lookup)
+			if err != nil {
+				return false, err
+			}
+			set = set || ok
+		case field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct:
+			// 先在临时值上设置，有字段被设置时才替换原来的指针
+			tmp := reflect.New(field.Type.Elem())
+			if !fv.IsNil() {
+				tmp.Elem().Set(fv.Elem())
+			}
+			ok, err := applyEnv(tmp.Elem(), name, lookup)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				fv.Set(tmp)
+				set = true
+			}
+		default:
+			raw, ok := lookup(name)
+			if !ok {
+				continue
+			}
+			if err := setValue(fv, raw); err != nil {
+				return false, fmt.Errorf("env %s: %w", name, err)
+			}
+			set = true
+		}
+	}
+	return set, nil
+}
+
+// 按字段类型解析环境变量的值
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -8,11 +8,31 @@ import (
 // 申请一个连接的使用权
 // 1. 这个调用应该由连接池来执行，引用后引用数进行原子加一
 // 2. 使用完毕后，引用者应该调用release进行原子减一
-func (c *Conn) acquire() int32 {
-	c.lastReferAt = time.Now()
-	ref := c.addConnRef()
+// 3. 连接的引用数已经达到最大时引用失败
+func (c *Conn) acquire() (int32, bool) {
 	c.unsetReady()
-	return ref
+	return c.refer()
+}
+
+// 引用连接，不改变连接的就绪状态
+// 1. 通过 CAS 保证引用数不会超过最大值
+func (c *Conn) refer() (int32, bool) {
+	for {
+		ref := atomic.LoadInt32(&c.ref)
+		if ref >= c.maxRef() {
+			return ref, false
+		}
+		if atomic.CompareAndSwapInt32(&c.ref, ref, ref+1) {
+			atomic.AddInt64(&c.counter.leases, 1)
+			c.lastReferAt.Store(time.Now().UnixNano())
+			return ref + 1, true
+		}
+	}
+}
+
+// 最近引用时间
+func (c *Conn) lastRefer() time.Time {
+	return time.Unix(0, c.lastReferAt.Load())
 }
 
 // 释放一个连接的使用权
@@ -21,11 +41,6 @@ func (c *Conn) release() int32 {
 	return c.subConnRef()
 }
 
-// 连接的引用数加一
-func (c *Conn) addConnRef() int32 {
-	return atomic.AddInt32(&c.ref, 1)
-}
-
 // 连接的引用数加一减一
 func (c *Conn) subConnRef() int32 {
 	ref := atomic.AddInt32(&c.ref, -1)
@@ -46,5 +61,10 @@ func (c *Conn) chkRef() int32 {
 
 // 判断连接引用数是否已经达到最大
 func (c *Conn) isMaxRef() bool {
-	return c.chkRef() >= c.refMax
+	return c.chkRef() >= c.maxRef()
+}
+
+// 连接的最大引用数，可以通过 Pool.Update 在运行时调整
+func (c *Conn) maxRef() int32 {
+	return atomic.LoadInt32(&c.refMax)
 }
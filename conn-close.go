@@ -9,20 +9,26 @@ func (c *Conn) setClosing(abs bool) bool {
 	if !abs && c.chkRef() > 0 || !c.longTimeNotUse() {
 		return false
 	}
-	c.closing = true
+	c.closing.Store(true)
 	return true
 }
 
+// 排空连接，不论引用情况直接标记为关闭中
+// 1. 用于端点被移除的场景，连接不再被引用，待引用数归零后被关闭
+func (c *Conn) drain() {
+	c.closing.Store(true)
+}
+
 // 长时间未使用
 func (c *Conn) longTimeNotUse() bool {
-	return c.lastReferAt.Add(c.closeWait).Before(time.Now())
+	return c.lastRefer().Add(c.closeWait).Before(time.Now())
 }
 
 // 判断一个连接是否可以被删除
 // 1. 连接需要处于关闭中状态
 // 2. 连接的引用数必须小于等于0
 func (c *Conn) removeAble() bool {
-	return c.closing && c.chkRef() <= 0
+	return c.closing.Load() && c.chkRef() <= 0
 }
 
 // 关闭这个连接
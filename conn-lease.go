@@ -0,0 +1,83 @@
+package gogrpcpool
+
+import (
+	"context"
+	"sync"
+)
+
+// 连接的一次引用
+// 1. 由 AcquireLease 取得，Release 只结束这一次引用的链路追踪，同一连接上的其他引用不受影响
+// 2. 连接池关闭时仍未归还的引用，在连接被强制关闭时结束链路追踪
+type Lease struct {
+	conn *Conn
+	pool *Pool
+	end  func()
+	once sync.Once
+}
+
+// 连接上未归还的引用
+type connLeases struct {
+	sync.Mutex
+	leases map[*Lease]struct{}
+}
+
+// 寻求一个可用的连接，返回这一次引用，直到 ctx 结束
+// 1. 配置了 Tracer 时，引用的持有过程从这里开始，到 Lease.Release 结束
+// 2. 使用完毕后需要调用 Lease.Release 释放，而不是 Pool.Release
+func (p *Pool) AcquireLease(ctx context.Context) (*Lease, error) {
+	conn, err := p.AcquireContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lease{conn: conn, pool: p, end: func() {}}
+	if p.opts.Tracer != nil {
+		l.end = p.opts.Tracer.TraceLease(ctx, p.opts.Name, conn)
+		conn.addLease(l)
+	}
+	return l, nil
+}
+
+// 引用的连接
+func (l *Lease) Conn() *Conn {
+	return l.conn
+}
+
+// 释放这一次引用，重复调用无效
+func (l *Lease) Release() {
+	l.once.Do(func() {
+		l.conn.removeLease(l)
+		l.end()
+		l.pool.Release(l.conn)
+	})
+}
+
+// 记录一个未归还的引用
+func (c *Conn) addLease(l *Lease) {
+	c.leases.Lock()
+	defer c.leases.Unlock()
+
+	if c.leases.leases == nil {
+		c.leases.leases = map[*Lease]struct{}{}
+	}
+	c.leases.leases[l] = struct{}{}
+}
+
+// 移除一个已归还的引用
+func (c *Conn) removeLease(l *Lease) {
+	c.leases.Lock()
+	defer c.leases.Unlock()
+	delete(c.leases.leases, l)
+}
+
+// 结束所有未归还的引用的链路追踪，用于连接被强制关闭的场景
+func (c *Conn) endLeases() {
+	c.leases.Lock()
+	leases := c.leases.leases
+	c.leases.leases = nil
+	c.leases.Unlock()
+
+	for l := range leases {
+		l.once.Do(l.end)
+	}
+}
@@ -0,0 +1,123 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 记录引用的开始和结束的 Tracer
+type leaseTracer struct {
+	sync.Mutex
+	started []string
+	ended   []string
+}
+
+type leaseKey struct{}
+
+func (t *leaseTracer) TraceAcquire(ctx context.Context, pool string) (context.Context, func(*Conn, error)) {
+	return ctx, func(*Conn, error) {}
+}
+
+func (t *leaseTracer) TraceDial(ctx context.Context, pool string, addr string) func(*Conn, error) {
+	return func(*Conn, error) {}
+}
+
+func (t *leaseTracer) TraceLease(ctx context.Context, pool string, conn *Conn) func() {
+	name, _ := ctx.Value(leaseKey{}).(string)
+
+	t.Lock()
+	defer t.Unlock()
+	t.started = append(t.started, name)
+	return func() {
+		t.Lock()
+		defer t.Unlock()
+		t.ended = append(t.ended, name)
+	}
+}
+
+func (t *leaseTracer) endedLeases() []string {
+	t.Lock()
+	defer t.Unlock()
+	return append([]string{}, t.ended...)
+}
+
+func TestLeaseRelease(t *testing.T) {
+	tracer := &leaseTracer{}
+	opts := testOptions(serve(t))
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 2
+	opts.Tracer = tracer
+	p := runPool(t, opts)
+
+	a, err := p.AcquireLease(context.WithValue(context.Background(), leaseKey{}, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := p.AcquireLease(context.WithValue(context.Background(), leaseKey{}, "b"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Conn() != b.Conn() {
+		t.Fatal("leases are not on the same conn")
+	}
+
+	// 同一连接上的引用各自结束
+	b.Release()
+	if ended := tracer.endedLeases(); len(ended) != 1 || ended[0] != "b" {
+		t.Fatalf("ended = %v after releasing b, want [b]", ended)
+	}
+
+	// 重复释放无效
+	b.Release()
+	if stats := p.Stats(); stats.RefCount != 1 {
+		t.Fatalf("refs = %d after releasing b twice, want 1", stats.RefCount)
+	}
+
+	a.Release()
+	if ended := tracer.endedLeases(); len(ended) != 2 || ended[1] != "a" {
+		t.Fatalf("ended = %v, want [b a]", ended)
+	}
+
+	// 直接使用 Acquire、Release 不追踪引用
+	conn, err := p.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Release(conn)
+	if n := len(tracer.started); n != 2 {
+		t.Fatalf("started %d leases, want 2", n)
+	}
+}
+
+func TestLeaseAbandoned(t *testing.T) {
+	tracer := &leaseTracer{}
+	opts := testOptions(serve(t))
+	opts.Tracer = tracer
+	p, err := NewPool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Run()
+
+	lease, err := p.AcquireLease(context.WithValue(context.Background(), leaseKey{}, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 强制关闭时结束未归还的引用，之后的 Release 不会再次结束
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var ce *CloseError
+	if err := p.Close(ctx); !errors.As(err, &ce) || ce.Abandoned != 1 {
+		t.Fatalf("Close = %v, want 1 abandoned lease", err)
+	}
+	lease.Release()
+	if ended := tracer.endedLeases(); len(ended) != 1 || ended[0] != "a" {
+		t.Fatalf("ended = %v, want [a]", ended)
+	}
+}
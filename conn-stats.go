@@ -0,0 +1,95 @@
+package gogrpcpool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+// 连接上的请求统计
+// 1. 通过拨号时注入的 stats.Handler 统计每个 RPC 的结果和耗时
+// 2. 租用次数在每次引用连接时累加
+type connCounter struct {
+	leases       int64
+	rpcs         int64
+	failures     int64
+	latencyNanos int64
+	latencyMax   int64
+	codes        [codes.Unauthenticated + 1]int64 // 按状态码统计 RPC 次数
+}
+
+// 连接上的 RPC 统计
+type RPCStats struct {
+	Total    int64            // RPC 总数
+	Failures int64            // 状态码不为 OK 的 RPC 数
+	Codes    map[string]int64 // 按状态码统计的 RPC 数
+	Mean     time.Duration    // 平均耗时
+	Max      time.Duration    // 最长耗时
+}
+
+// 记录一次 RPC
+func (c *connCounter) observeRPC(code codes.Code, latency time.Duration) {
+	atomic.AddInt64(&c.rpcs, 1)
+	if code != codes.OK {
+		atomic.AddInt64(&c.failures, 1)
+	}
+	if int(code) < len(c.codes) {
+		atomic.AddInt64(&c.codes[code], 1)
+	}
+
+	atomic.AddInt64(&c.latencyNanos, int64(latency))
+	for {
+		max := atomic.LoadInt64(&c.latencyMax)
+		if int64(latency) <= max || atomic.CompareAndSwapInt64(&c.latencyMax, max, int64(latency)) {
+			break
+		}
+	}
+}
+
+// RPC 统计快照
+func (c *connCounter) rpcStats() RPCStats {
+	s := RPCStats{
+		Total:    atomic.LoadInt64(&c.rpcs),
+		Failures: atomic.LoadInt64(&c.failures),
+		Codes:    map[string]int64{},
+		Max:      time.Duration(atomic.LoadInt64(&c.latencyMax)),
+	}
+
+	if s.Total > 0 {
+		s.Mean = time.Duration(atomic.LoadInt64(&c.latencyNanos) / s.Total)
+	}
+
+	for i := range c.codes {
+		if n := atomic.LoadInt64(&c.codes[i]); n > 0 {
+			s.Codes[codes.Code(i).String()] = n
+		}
+	}
+	return s
+}
+
+// 注入到连接上的 stats.Handler，只关心客户端 RPC 结束事件
+type connStatsHandler struct {
+	counter *connCounter
+}
+
+func (h *connStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *connStatsHandler) HandleRPC(_ context.Context, s stats.RPCStats) {
+	end, ok := s.(*stats.End)
+	if !ok || !end.IsClient() {
+		return
+	}
+	h.counter.observeRPC(status.Code(end.Error), end.EndTime.Sub(end.BeginTime))
+}
+
+func (h *connStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *connStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
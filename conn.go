@@ -1,60 +1,152 @@
 package gogrpcpool
 
 import (
-	"fmt"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 )
 
 /*
 grpc 客户端连接管理
 
 1. 实例化时设置 ref 为0，每次引用时加一，释放时减一
-2. 连接被建立时，需要启动一个goroutine来运行 run() 方法，该方法会实时检测连接的状态，来实时将可用状态下的连接推入到 readyTunnel 中
+2. 连接被建立时，需要启动一个goroutine来运行 run() 方法，该方法会实时检测连接的状态，来实时将可用状态下的连接推入到 readyTunnel 中，连接被标记为关闭中或者连接池关闭时该 goroutine 退出
 3. 当 closing = true 时，连接将不允许再被引用，也就不能够再推到 readyTunnel 中，也意味着 ref 的值不会再增加
 4. 当 closing = true 且 ref为0 时, 在Pool中会被 idleConnManager 关闭和删除
-5. 当 ref >= refMax 时，连接也将不能够再被引用，也就不能够再推到 readyTunnel 中，但是 run 方法会每隔1ms进行一次ref检测，当ref < refMax 时，连接将再次被推入到 readyTunnel 中
+5. 当 ref >= refMax 或者连接不健康时，连接也将不能够再被引用，也就不能够再推到 readyTunnel 中，但是 run 方法会每隔1ms进行一次ref检测，当ref < refMax 时，连接将再次被推入到 readyTunnel 中
 6. 仅当 ref <= 0 且 lastReferAt 在 closeWait 之前，才能将 closing 设置为 true
 */
 type Conn struct {
+	// 连接编号，在进程内唯一
+	id uint64
+	// 建立时间
+	createdAt time.Time
+
 	// grpc ClientConn
 	conn *grpc.ClientConn
+	// 租用次数及 RPC 统计
+	counter *connCounter
+	// 通过 AcquireLease 取得且未归还的引用
+	leases connLeases
+	// 连接所属的连接池
+	pool *Pool
+	// 连接所属的端点
+	endpoint Endpoint
+	// 连接所属的端点是否与调用方处于同一位置
+	local bool
 
 	// 连接的引用次数， 每 acquire 一次加一，连接归还时减一
 	ref    int32
 	refMax int32
 
-	// 最近引用时间
-	lastReferAt time.Time
+	// 最近引用时间，unix 纳秒，直接引用的路径上会被并发更新
+	lastReferAt atomic.Int64
 
 	// 关闭等待周期, 即：当最后一次引用时间距离当前时间超过 closeWait 时，连接可以被关闭
 	closeWait time.Duration
 	// 关闭状态, 当连接需要准备关闭时，将其设置为true，之后连接将不能够再推到 就绪管道 readyTunnel 中
-	closing bool
+	closing atomic.Bool
 	// 就绪状态, 当连接被成功推入就绪管道 readyTunnel 中时，被设定为 true, 当连接被取用时，重置为false
-	readying bool
+	readying atomic.Bool
 
-	// 就绪管道，该管道是对 Pool 中的 readyTunnel 的引用
-	// 在Pool中每当需要连接时，会通过 <-readyTunnel 取用连接
+	// 就绪管道，未加入连接池的连接就绪时推入这里
+	// 加入连接池的连接推入连接池当前的就绪队列，在Pool中每当需要连接时，会从就绪队列取用连接
 	readyTunnel chan<- *Conn
 }
 
 func (c *Conn) run() {
+	done := c.done()
+	tricker := time.NewTicker(time.Millisecond * 1)
+	defer tricker.Stop()
+
+	// 连接的 connectivity 状态变化时通知连接池刷新状态
+	// 配置了 ReconnectIdle 时，连接池中的连接进入 Idle 后主动重连，以便及时发现端点不可用
+	var last connectivity.State = -1
 	for {
-		if c.closing {
+		if c.closing.Load() {
 			break
 		}
 
-		// 连接的引用次数满了 或者 连接已经处于就绪状态则等待 则睡眠等待
-		if c.isMaxRef() || c.readying {
-			time.Sleep(time.Millisecond * 1)
+		if c.pool != nil && c.conn != nil {
+			if state := c.conn.GetState(); state != last {
+				last = state
+				if state == connectivity.Idle && c.pool.opts.ReconnectIdle {
+					c.conn.Connect()
+				}
+				c.pool.onConnStateChange()
+			}
+		}
+
+		// 连接的引用次数满了 或者 连接已经处于就绪状态 或者 连接不健康 则睡眠等待
+		if c.isMaxRef() || c.readying.Load() || !c.healthy() {
+			select {
+			case <-done:
+				return
+			case <-tricker.C:
+			}
 			continue
 		}
 
-		c.readyTunnel <- c
+		tunnel, q := c.tunnel()
+		if !c.push(tunnel, q, done) {
+			return
+		}
+	}
+}
+
+// 将连接推入就绪通道，连接池关闭时返回 false
+// 1. q 为 tunnel 所属的就绪队列，就绪队列被替换时放弃推入，由 run 重新推入新的就绪队列
+// 2. 推入的同时就绪队列被替换时，旧队列可能已经被清空且不会再被读取，移除就绪状态使连接被重新推入
+func (c *Conn) push(tunnel chan<- *Conn, q *readyQueue, done <-chan struct{}) bool {
+	var resized <-chan struct{}
+	if q != nil {
+		resized = q.resized
+	}
+
+	select {
+	case tunnel <- c:
 		c.setReady()
+		if q != nil && c.pool.readyQueue() != q {
+			c.unsetReady()
+		}
+	case <-resized:
+	case <-done:
+		return false
+	}
+	return true
+}
+
+// 连接所属的连接池关闭的信号，未加入连接池的连接没有这个信号
+func (c *Conn) done() <-chan struct{} {
+	if c.pool == nil {
+		return nil
 	}
+	return c.pool.done
+}
+
+// 连接就绪时应推入的通道，以及该通道所属的就绪队列，未加入连接池的连接没有就绪队列
+func (c *Conn) tunnel() (chan<- *Conn, *readyQueue) {
+	if c.pool == nil {
+		return c.readyTunnel, nil
+	}
+	q := c.pool.readyQueue()
+	return q.tunnel(c.local), q
+}
+
+// 连接编号
+func (c *Conn) ID() uint64 {
+	return c.id
+}
+
+// 连接是否健康，处于连接失败或者已关闭状态的连接不能被使用
+func (c *Conn) healthy() bool {
+	if c.conn == nil {
+		return false
+	}
+	state := c.conn.GetState()
+	return state != connectivity.TransientFailure && state != connectivity.Shutdown
 }
 
 // 引用grpc客户端连接
@@ -62,7 +154,46 @@ func (c *Conn) Refer() *grpc.ClientConn {
 	return c.conn
 }
 
+// 连接所属的端点
+func (c *Conn) Endpoint() Endpoint {
+	return c.endpoint
+}
+
 // 描述信息
 func (c *Conn) Describe() string {
-	return fmt.Sprintf("ref: %v, closing: %v, readying: %v", c.chkRef(), c.closing, c.readying)
+	return c.stats(time.Now()).describe()
+}
+
+// 连接的状态快照
+func (c *Conn) stats(now time.Time) ConnStats {
+	ref := c.chkRef()
+	state := ConnStateIdle
+	switch {
+	case c.closing.Load():
+		state = ConnStateClosing
+	case ref >= c.maxRef():
+		state = ConnStateBusy
+	case ref > 0:
+		state = ConnStateActive
+	}
+
+	connectivity := ""
+	if c.conn != nil {
+		connectivity = c.conn.GetState().String()
+	}
+
+	return ConnStats{
+		ID:           c.id,
+		Addr:         c.endpoint.Addr,
+		Locality:     localityName(c.endpoint),
+		Age:          now.Sub(c.createdAt),
+		Idle:         now.Sub(c.lastRefer()),
+		Ref:          ref,
+		MaxRef:       c.maxRef(),
+		State:        state,
+		Ready:        c.readying.Load(),
+		Connectivity: connectivity,
+		Leases:       atomic.LoadInt64(&c.counter.leases),
+		RPC:          c.counter.rpcStats(),
+	}
 }
@@ -0,0 +1,237 @@
+// 基于 Consul 的端点发现
+//
+// Watcher 通过 Consul 健康检查接口 /v1/health/service/<service>?passing=1 的阻塞查询（blocking query）
+// 长轮询服务实例，每当 X-Consul-Index 变化时将所有健康检查通过的实例更新为连接池的端点集合
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+)
+
+var ErrServiceNotSet = errors.New("consul service not set")
+
+type Options struct {
+	Address    string        // Consul HTTP 地址，默认 http://127.0.0.1:8500
+	Service    string        // 服务名称
+	Tag        string        // 按标签过滤服务实例，可以为空
+	Datacenter string        // 数据中心，为空时使用 agent 所在的数据中心
+	Token      string        // ACL token
+	WaitTime   time.Duration // 阻塞查询的最长等待时间，默认 5m
+	Backoff    time.Duration // 查询失败后的最大退避时间，默认 30s
+	HTTPClient *http.Client  // 默认使用 http.DefaultClient
+	OnError    func(error)   // 查询失败时的回调，默认输出到 gogrpcpool.DefaultLogger
+}
+
+// 健康检查接口返回的服务实例
+type serviceEntry struct {
+	Node struct {
+		Node       string
+		Address    string
+		Datacenter string
+	}
+	Service struct {
+		ID      string
+		Service string
+		Address string
+		Port    int
+		Tags    []string
+		Meta    map[string]string
+		Weights struct {
+			Passing int32
+		}
+	}
+	Checks []struct {
+		CheckID string
+		Status  string
+	}
+}
+
+type Watcher struct {
+	opts Options
+}
+
+// 实例化 Consul 端点发现
+func New(opts Options) *Watcher {
+	if opts.Address == "" {
+		opts.Address = "http://127.0.0.1:8500"
+	}
+
+	if opts.WaitTime <= time.Duration(0) {
+		opts.WaitTime = time.Minute * 5
+	}
+
+	if opts.Backoff <= time.Duration(0) {
+		opts.Backoff = time.Second * 30
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			gogrpcpool.DefaultLogger().Error("consul discovery failed", "service", opts.Service, "error", err)
+		}
+	}
+
+	return &Watcher{opts: opts}
+}
+
+// 长轮询服务实例
+// 1. 查询失败时按指数退避重试，不会退出
+// 2. 仅在端点列表真正发生变化时调用 update
+func (w *Watcher) Watch(ctx context.Context, update func([]gogrpcpool.Endpoint)) error {
+	if w.opts.Service == "" {
+		return ErrServiceNotSet
+	}
+
+	index := uint64(0)
+	backoff := time.Duration(0)
+	var last []gogrpcpool.Endpoint
+
+	for {
+		eps, next, err := w.query(ctx, index)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			w.opts.OnError(err)
+
+			backoff = nextBackoff(backoff, w.opts.Backoff)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		// 索引回退时需要从头开始查询，避免阻塞在错误的索引上
+		if next < index {
+			next = 0
+		}
+		index = next
+
+		if !reflect.DeepEqual(eps, last) {
+			last = eps
+			update(eps)
+		}
+	}
+}
+
+// 执行一次阻塞查询，返回端点列表及新的索引
+func (w *Watcher) query(ctx context.Context, index uint64) ([]gogrpcpool.Endpoint, uint64, error) {
+	query := url.Values{}
+	query.Set("passing", "1")
+	query.Set("index", strconv.FormatUint(index, 10))
+	query.Set("wait", fmt.Sprintf("%ds", int(w.opts.WaitTime.Seconds())))
+	if w.opts.Tag != "" {
+		query.Set("tag", w.opts.Tag)
+	}
+	if w.opts.Datacenter != "" {
+		query.Set("dc", w.opts.Datacenter)
+	}
+
+	u := fmt.Sprintf("%s/v1/health/service/%s?%s", w.opts.Address, url.PathEscape(w.opts.Service), query.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if w.opts.Token != "" {
+		req.Header.Set("X-Consul-Token", w.opts.Token)
+	}
+
+	resp, err := w.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("query service %s: unexpected status %s", w.opts.Service, resp.Status)
+	}
+
+	next, err := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query service %s: invalid X-Consul-Index: %w", w.opts.Service, err)
+	}
+
+	entries := []serviceEntry{}
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, 0, fmt.Errorf("query service %s: %w", w.opts.Service, err)
+	}
+	return toEndpoints(entries), next, nil
+}
+
+// 将服务实例转换为端点
+// 1. 服务未设置地址时使用节点地址，没有地址或端口的实例被忽略
+// 2. 存在未通过的健康检查的实例被忽略，不依赖 passing 参数在服务端的过滤
+// 3. 服务的 Meta 以及节点名称、数据中心作为端点的元数据
+func toEndpoints(entries []serviceEntry) []gogrpcpool.Endpoint {
+	eps := []gogrpcpool.Endpoint{}
+	for _, entry := range entries {
+		if !entry.passing() {
+			continue
+		}
+
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		if host == "" || entry.Service.Port <= 0 {
+			continue
+		}
+
+		meta := map[string]string{
+			"node":       entry.Node.Node,
+			"datacenter": entry.Node.Datacenter,
+		}
+		for k, v := range entry.Service.Meta {
+			meta[k] = v
+		}
+
+		eps = append(eps, gogrpcpool.Endpoint{
+			Addr:     net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)),
+			Weight:   entry.Service.Weights.Passing,
+			Metadata: meta,
+		})
+	}
+	return eps
+}
+
+// 实例的健康检查是否全部通过
+func (e serviceEntry) passing() bool {
+	for _, check := range e.Checks {
+		if check.Status != "passing" {
+			return false
+		}
+	}
+	return true
+}
+
+// 计算下一次的退避时间，从 1s 开始翻倍，不超过 max
+func nextBackoff(cur, max time.Duration) time.Duration {
+	if cur <= time.Duration(0) {
+		cur = time.Second
+		if cur > max {
+			return max
+		}
+		return cur
+	}
+	if cur *= 2; cur > max {
+		return max
+	}
+	return cur
+}
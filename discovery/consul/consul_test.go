@@ -0,0 +1,249 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+)
+
+// 模拟的一次查询响应
+type response struct {
+	status  int
+	index   uint64
+	entries []map[string]any
+}
+
+// 按顺序返回预设响应的 Consul 服务，预设响应用完后阻塞直到请求结束
+type fakeConsul struct {
+	t *testing.T
+
+	mu        sync.Mutex
+	responses []response
+	indexes   []string
+	passing   []string
+	times     []time.Time
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/health/service/greeter" {
+		f.t.Errorf("unexpected path %s", r.URL.Path)
+	}
+
+	f.mu.Lock()
+	f.indexes = append(f.indexes, r.URL.Query().Get("index"))
+	f.passing = append(f.passing, r.URL.Query().Get("passing"))
+	f.times = append(f.times, time.Now())
+	if len(f.responses) == 0 {
+		f.mu.Unlock()
+		<-r.Context().Done()
+		return
+	}
+	resp := f.responses[0]
+	f.responses = f.responses[1:]
+	f.mu.Unlock()
+
+	if resp.status != http.StatusOK {
+		w.WriteHeader(resp.status)
+		return
+	}
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(resp.index, 10))
+	json.NewEncoder(w).Encode(resp.entries)
+}
+
+// 请求的次数
+func (f *fakeConsul) requests() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.indexes)
+}
+
+// 服务实例
+func entry(addr string, port int, status string) map[string]any {
+	e := map[string]any{
+		"Node":    map[string]any{"Node": "node-" + addr, "Address": addr, "Datacenter": "dc1"},
+		"Service": map[string]any{"Service": "greeter", "Port": port},
+	}
+	if status != "" {
+		e["Checks"] = []map[string]any{{"CheckID": "serfHealth", "Status": "passing"}, {"CheckID": "service:greeter", "Status": status}}
+	}
+	return e
+}
+
+// 启动 Watch，直到预设响应全部被消费且没有新的请求
+func watch(t *testing.T, f *fakeConsul, opts Options) ([][]gogrpcpool.Endpoint, []error) {
+	t.Helper()
+
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+
+	var mu sync.Mutex
+	updates := [][]gogrpcpool.Endpoint{}
+	errs := []error{}
+
+	opts.Address = srv.URL
+	opts.Service = "greeter"
+	opts.OnError = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}
+
+	want := len(f.responses) + 1
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- New(opts).Watch(ctx, func(eps []gogrpcpool.Endpoint) {
+			mu.Lock()
+			defer mu.Unlock()
+			updates = append(updates, eps)
+		})
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for f.requests() < want {
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d requests, want %d", f.requests(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Watch returned %v, want context.Canceled", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	return updates, errs
+}
+
+func TestWatchIndex(t *testing.T) {
+	f := &fakeConsul{t: t, responses: []response{
+		{status: http.StatusOK, index: 5, entries: []map[string]any{entry("10.0.0.1", 8080, "passing")}},
+		{status: http.StatusOK, index: 7, entries: []map[string]any{entry("10.0.0.2", 8080, "passing")}},
+		{status: http.StatusOK, index: 3, entries: []map[string]any{entry("10.0.0.3", 8080, "passing")}},
+		{status: http.StatusOK, index: 4, entries: []map[string]any{entry("10.0.0.4", 8080, "passing")}},
+	}}
+
+	updates, _ := watch(t, f, Options{})
+
+	// 索引 3 小于 7，回退后从 0 开始查询
+	want := []string{"0", "5", "7", "0", "4"}
+	if len(f.indexes) != len(want) {
+		t.Fatalf("indexes = %v, want %v", f.indexes, want)
+	}
+	for i := range want {
+		if f.indexes[i] != want[i] {
+			t.Fatalf("indexes = %v, want %v", f.indexes, want)
+		}
+		if f.passing[i] != "1" {
+			t.Fatalf("request %d passing = %q, want 1", i, f.passing[i])
+		}
+	}
+
+	if len(updates) != 4 {
+		t.Fatalf("got %d updates, want 4", len(updates))
+	}
+	if got := updates[3][0].Addr; got != "10.0.0.4:8080" {
+		t.Fatalf("last update addr = %s, want 10.0.0.4:8080", got)
+	}
+}
+
+func TestWatchFilter(t *testing.T) {
+	service := entry("10.0.0.5", 9090, "")
+	service["Service"].(map[string]any)["Address"] = "10.1.0.5"
+	service["Service"].(map[string]any)["Meta"] = map[string]string{"zone": "z1"}
+	service["Service"].(map[string]any)["Weights"] = map[string]any{"Passing": 3}
+
+	f := &fakeConsul{t: t, responses: []response{
+		{status: http.StatusOK, index: 1, entries: []map[string]any{
+			entry("10.0.0.1", 8080, "passing"),
+			entry("10.0.0.2", 8080, "critical"),
+			entry("10.0.0.3", 8080, "warning"),
+			entry("10.0.0.4", 0, "passing"),
+			entry("", 8080, "passing"),
+			service,
+		}},
+	}}
+
+	updates, _ := watch(t, f, Options{})
+	if len(updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(updates))
+	}
+
+	eps := updates[0]
+	if len(eps) != 2 {
+		t.Fatalf("got endpoints %v, want 2", eps)
+	}
+	if eps[0].Addr != "10.0.0.1:8080" {
+		t.Fatalf("endpoint 0 = %s, want 10.0.0.1:8080", eps[0].Addr)
+	}
+	if eps[1].Addr != "10.1.0.5:9090" || eps[1].Weight != 3 || eps[1].Metadata["zone"] != "z1" || eps[1].Metadata["datacenter"] != "dc1" {
+		t.Fatalf("endpoint 1 = %+v", eps[1])
+	}
+}
+
+func TestWatchBackoff(t *testing.T) {
+	f := &fakeConsul{t: t, responses: []response{
+		{status: http.StatusInternalServerError},
+		{status: http.StatusBadGateway},
+		{status: http.StatusOK, index: 2, entries: []map[string]any{entry("10.0.0.1", 8080, "passing")}},
+	}}
+
+	updates, errs := watch(t, f, Options{Backoff: 50 * time.Millisecond})
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors, want 2", len(errs))
+	}
+	if len(updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(updates))
+	}
+
+	// 失败后至少等待退避时间再重试
+	for i := 1; i <= 2; i++ {
+		if d := f.times[i].Sub(f.times[i-1]); d < 50*time.Millisecond {
+			t.Fatalf("retry %d after %v, want >= 50ms", i, d)
+		}
+	}
+
+	// 失败后不前进索引
+	if f.indexes[1] != "0" || f.indexes[2] != "0" || f.indexes[3] != "2" {
+		t.Fatalf("indexes = %v", f.indexes)
+	}
+}
+
+func TestWatchUnchanged(t *testing.T) {
+	eps := []map[string]any{entry("10.0.0.1", 8080, "passing"), entry("10.0.0.2", 8080, "passing")}
+	f := &fakeConsul{t: t, responses: []response{
+		{status: http.StatusOK, index: 1, entries: eps},
+		{status: http.StatusOK, index: 2, entries: eps},
+		{status: http.StatusOK, index: 3, entries: append(eps, entry("10.0.0.3", 8080, "critical"))},
+	}}
+
+	updates, _ := watch(t, f, Options{})
+	if len(updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(updates))
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	cases := []struct {
+		cur, max, want time.Duration
+	}{
+		{0, 30 * time.Second, time.Second},
+		{0, 50 * time.Millisecond, 50 * time.Millisecond},
+		{time.Second, 30 * time.Second, 2 * time.Second},
+		{20 * time.Second, 30 * time.Second, 30 * time.Second},
+	}
+	for _, c := range cases {
+		if got := nextBackoff(c.cur, c.max); got != c.want {
+			t.Errorf("nextBackoff(%v, %v) = %v, want %v", c.cur, c.max, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// 基于文件的端点发现
+//
+// 文件内容为 JSON 或 YAML 格式的端点列表，根据扩展名选择解析方式（.json / .yaml / .yml），例如：
+//
+//	endpoints:
+//	  - addr: 10.0.0.1:50051
+//	    weight: 2
+//	    metadata:
+//	      zone: zone-a
+//	  - addr: 10.0.0.2:50051
+//
+// Watcher 会周期性检查文件的修改时间和大小，文件变化后重新加载并校验，校验通过才会更新连接池的端点集合，
+// 校验失败时保留上一次的端点集合不变
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported endpoint file format")
+	ErrNoEndpoints       = errors.New("no endpoints in file")
+)
+
+type Options struct {
+	Path     string        // 端点列表文件路径
+	Interval time.Duration // 检查文件变化的周期，默认 5s
+	OnError  func(error)   // 加载或校验失败时的回调，默认输出到 gogrpcpool.DefaultLogger
+}
+
+// 端点列表文件的结构
+type document struct {
+	Endpoints []gogrpcpool.Endpoint `json:"endpoints" yaml:"endpoints"`
+}
+
+type Watcher struct {
+	opts Options
+}
+
+// 实例化文件端点发现
+func New(opts Options) *Watcher {
+	if opts.Interval <= time.Duration(0) {
+		opts.Interval = time.Second * 5
+	}
+
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			gogrpcpool.DefaultLogger().Error("file discovery failed", "path", opts.Path, "error", err)
+		}
+	}
+
+	return &Watcher{opts: opts}
+}
+
+// 监听文件变化
+// 1. 首次加载失败不会退出，而是继续等待文件被修正
+// 2. 仅在端点列表真正发生变化时调用 update
+func (w *Watcher) Watch(ctx context.Context, update func([]gogrpcpool.Endpoint)) error {
+	tricker := time.NewTicker(w.opts.Interval)
+	defer tricker.Stop()
+
+	var modTime time.Time
+	var size int64 = -1
+	var last []gogrpcpool.Endpoint
+
+	for {
+		info, err := os.Stat(w.opts.Path)
+		if err != nil {
+			w.opts.OnError(err)
+		} else if !info.ModTime().Equal(modTime) || info.Size() != size {
+			eps, err := Load(w.opts.Path)
+			if err != nil {
+				w.opts.OnError(err)
+			} else if !reflect.DeepEqual(eps, last) {
+				last = eps
+				update(eps)
+			}
+
+			// 加载失败时同样记录，避免文件未变化时反复报错
+			modTime = info.ModTime()
+			size = info.Size()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-tricker.C:
+		}
+	}
+}
+
+// 加载并校验端点列表文件
+func Load(path string) ([]gogrpcpool.Endpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := document{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&doc)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&doc)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	if err := validate(doc.Endpoints); err != nil {
+		return nil, fmt.Errorf("validate %s: %w", path, err)
+	}
+	return doc.Endpoints, nil
+}
+
+// 校验端点列表
+// 1. 端点列表不能为空，避免文件被误清空时排空所有连接
+// 2. 地址不能为空且不能重复，权重不能为负数
+func validate(eps []gogrpcpool.Endpoint) error {
+	if len(eps) == 0 {
+		return ErrNoEndpoints
+	}
+
+	seen := map[string]bool{}
+	for i, ep := range eps {
+		if strings.TrimSpace(ep.Addr) == "" {
+			return fmt.Errorf("endpoints[%d]: addr is empty", i)
+		}
+		if seen[ep.Addr] {
+			return fmt.Errorf("endpoints[%d]: duplicate addr %q", i, ep.Addr)
+		}
+		if ep.Weight < 0 {
+			return fmt.Errorf("endpoints[%d]: negative weight %d", i, ep.Weight)
+		}
+		seen[ep.Addr] = true
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+)
+
+// 在临时目录中写入端点列表文件
+func writeFile(t *testing.T, dir, name, data string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		data string
+		want []gogrpcpool.Endpoint
+		err  string
+	}{
+		{
+			name: "yaml",
+			file: "eps.yaml",
+			data: "endpoints:\n  - addr: 10.0.0.1:50051\n    weight: 2\n    metadata:\n      zone: zone-a\n  - addr: 10.0.0.2:50051\n",
+			want: []gogrpcpool.Endpoint{
+				{Addr: "10.0.0.1:50051", Weight: 2, Metadata: map[string]string{"zone": "zone-a"}},
+				{Addr: "10.0.0.2:50051"},
+			},
+		},
+		{
+			name: "json",
+			file: "eps.json",
+			data: `{"endpoints": [{"addr": "10.0.0.1:50051", "weight": 3}]}`,
+			want: []gogrpcpool.Endpoint{{Addr: "10.0.0.1:50051", Weight: 3}},
+		},
+		{
+			name: "empty list",
+			file: "eps.yml",
+			data: "endpoints: []\n",
+			err:  ErrNoEndpoints.Error(),
+		},
+		{
+			name: "empty addr",
+			file: "eps.json",
+			data: `{"endpoints": [{"addr": " "}]}`,
+			err:  "endpoints[0]: addr is empty",
+		},
+		{
+			name: "duplicate addr",
+			file: "eps.json",
+			data: `{"endpoints": [{"addr": "10.0.0.1:50051"}, {"addr": "10.0.0.1:50051"}]}`,
+			err:  `endpoints[1]: duplicate addr "10.0.0.1:50051"`,
+		},
+		{
+			name: "negative weight",
+			file: "eps.yaml",
+			data: "endpoints:\n  - addr: 10.0.0.1:50051\n    weight: -1\n",
+			err:  "endpoints[0]: negative weight -1",
+		},
+		{
+			name: "unknown field json",
+			file: "eps.json",
+			data: `{"endpoints": [{"addr": "10.0.0.1:50051", "wieght": 1}]}`,
+			err:  `unknown field "wieght"`,
+		},
+		{
+			name: "unknown field yaml",
+			file: "eps.yaml",
+			data: "endpoints:\n  - addr: 10.0.0.1:50051\n    wieght: 1\n",
+			err:  "field wieght not found",
+		},
+		{
+			name: "unsupported format",
+			file: "eps.txt",
+			data: "10.0.0.1:50051",
+			err:  ErrUnsupportedFormat.Error(),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), c.file, c.data)
+
+			eps, err := Load(path)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("Load = %v, want error containing %q", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(eps) != len(c.want) {
+				t.Fatalf("Load = %+v, want %+v", eps, c.want)
+			}
+			for i := range eps {
+				if eps[i].Addr != c.want[i].Addr || eps[i].Weight != c.want[i].Weight ||
+					eps[i].Metadata["zone"] != c.want[i].Metadata["zone"] {
+					t.Fatalf("endpoints[%d] = %+v, want %+v", i, eps[i], c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Load = %v, want os.ErrNotExist", err)
+	}
+}
+
+// 等待下一次端点更新
+func nextUpdate(t *testing.T, updates <-chan []gogrpcpool.Endpoint) []gogrpcpool.Endpoint {
+	t.Helper()
+
+	select {
+	case eps := <-updates:
+		return eps
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update")
+		return nil
+	}
+}
+
+func TestWatchReload(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "eps.yaml", "endpoints:\n  - addr: a:1\n")
+
+	errs := make(chan error, 10)
+	w := New(Options{Path: path, Interval: 5 * time.Millisecond, OnError: func(err error) { errs <- err }})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan []gogrpcpool.Endpoint, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Watch(ctx, func(eps []gogrpcpool.Endpoint) { updates <- eps })
+	}()
+
+	if eps := nextUpdate(t, updates); len(eps) != 1 || eps[0].Addr != "a:1" {
+		t.Fatalf("first update = %+v", eps)
+	}
+
+	// 文件变化后重新加载
+	writeFile(t, dir, "eps.yaml", "endpoints:\n  - addr: a:1\n  - addr: b:2\n")
+	if eps := nextUpdate(t, updates); len(eps) != 2 || eps[1].Addr != "b:2" {
+		t.Fatalf("update after change = %+v", eps)
+	}
+
+	// 校验失败时保留上一次的端点集合，只回调 OnError
+	writeFile(t, dir, "eps.yaml", "endpoints: []\n")
+	select {
+	case err := <-errs:
+		if !errors.Is(err, ErrNoEndpoints) {
+			t.Fatalf("OnError(%v), want ErrNoEndpoints", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("invalid file was not reported")
+	}
+
+	// 端点列表与上一次相同时不会更新
+	writeFile(t, dir, "eps.yaml", "endpoints:\n  - addr: a:1\n  - addr: b:2\n")
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case eps := <-updates:
+		t.Fatalf("update with unchanged endpoints %+v", eps)
+	default:
+	}
+
+	writeFile(t, dir, "eps.yaml", "endpoints:\n  - addr: c:3\n")
+	if eps := nextUpdate(t, updates); len(eps) != 1 || eps[0].Addr != "c:3" {
+		t.Fatalf("update after fix = %+v, want only c:3", eps)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Watch = %v, want context.Canceled", err)
+	}
+	select {
+	case eps := <-updates:
+		t.Fatalf("unexpected update %+v", eps)
+	default:
+	}
+}
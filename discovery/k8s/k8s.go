@@ -0,0 +1,227 @@
+// 基于 Kubernetes EndpointSlice 的端点发现
+//
+// Watcher 监听 headless service 对应的 EndpointSlice（通过 kubernetes.io/service-name 标签关联），
+// 为每个 pod 地址维护一个端点：
+//  1. ready 的端点被加入端点集合，未设置 ready 时使用 serving
+//  2. terminating 的端点被移出端点集合，连接池会将其上的连接标记为关闭中，待引用归零后关闭
+//  3. 端点的 zone、节点名称和 pod 名称作为端点的元数据
+//
+// Watcher 仅依赖 kubernetes.Interface，可以使用 client-go 的 fake clientset 进行测试。
+// 该包是独立的 module（github.com/biandoucheng/go-grpc-pool/discovery/k8s），只使用连接池的项目不会引入 client-go 依赖
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	ErrClientNotSet  = errors.New("kubernetes client not set")
+	ErrServiceNotSet = errors.New("kubernetes service not set")
+)
+
+type Options struct {
+	Client    kubernetes.Interface // kubernetes 客户端
+	Namespace string               // 服务所在的命名空间，默认 default
+	Service   string               // 服务名称
+	PortName  string               // 使用的端口名称，为空时使用 EndpointSlice 的第一个端口
+	Backoff   time.Duration        // list/watch 失败后的重试间隔，默认 1s
+	OnError   func(error)          // list/watch 失败时的回调，默认输出到 gogrpcpool.DefaultLogger
+}
+
+type Watcher struct {
+	opts Options
+}
+
+// 实例化 EndpointSlice 端点发现
+func New(opts Options) *Watcher {
+	if opts.Namespace == "" {
+		opts.Namespace = "default"
+	}
+
+	if opts.Backoff <= time.Duration(0) {
+		opts.Backoff = time.Second
+	}
+
+	if opts.OnError == nil {
+		opts.OnError = func(err error) {
+			gogrpcpool.DefaultLogger().Error("kubernetes discovery failed", "namespace", opts.Namespace, "service", opts.Service, "error", err)
+		}
+	}
+
+	return &Watcher{opts: opts}
+}
+
+// 监听 EndpointSlice 变化
+// 1. 先 list 全量的 EndpointSlice，再从对应的 resourceVersion 开始 watch
+// 2. watch 被服务端关闭或出错时重新 list，不会退出
+// 3. 仅在端点列表真正发生变化时调用 update
+func (w *Watcher) Watch(ctx context.Context, update func([]gogrpcpool.Endpoint)) error {
+	if w.opts.Client == nil {
+		return ErrClientNotSet
+	}
+
+	if w.opts.Service == "" {
+		return ErrServiceNotSet
+	}
+
+	var last []gogrpcpool.Endpoint
+	notify := func(slices map[string]*discoveryv1.EndpointSlice) {
+		eps := w.toEndpoints(slices)
+		if !reflect.DeepEqual(eps, last) {
+			last = eps
+			update(eps)
+		}
+	}
+
+	for {
+		err := w.listAndWatch(ctx, notify)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			w.opts.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.opts.Backoff):
+		}
+	}
+}
+
+// 执行一轮 list + watch，watch 结束时返回
+func (w *Watcher) listAndWatch(ctx context.Context, notify func(map[string]*discoveryv1.EndpointSlice)) error {
+	client := w.opts.Client.DiscoveryV1().EndpointSlices(w.opts.Namespace)
+	selector := discoveryv1.LabelServiceName + "=" + w.opts.Service
+
+	list, err := client.List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return fmt.Errorf("list endpointslices of %s/%s: %w", w.opts.Namespace, w.opts.Service, err)
+	}
+
+	slices := map[string]*discoveryv1.EndpointSlice{}
+	for i := range list.Items {
+		slices[list.Items[i].Name] = &list.Items[i]
+	}
+	notify(slices)
+
+	watcher, err := client.Watch(ctx, metav1.ListOptions{
+		LabelSelector:   selector,
+		ResourceVersion: list.ResourceVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("watch endpointslices of %s/%s: %w", w.opts.Namespace, w.opts.Service, err)
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if slice, ok := event.Object.(*discoveryv1.EndpointSlice); ok {
+					slices[slice.Name] = slice
+				}
+			case watch.Deleted:
+				if slice, ok := event.Object.(*discoveryv1.EndpointSlice); ok {
+					delete(slices, slice.Name)
+				}
+			case watch.Error:
+				return fmt.Errorf("watch endpointslices of %s/%s: %v", w.opts.Namespace, w.opts.Service, event.Object)
+			default:
+				continue
+			}
+			notify(slices)
+		}
+	}
+}
+
+// 将 EndpointSlice 转换为端点列表，按地址排序以便比较变化
+func (w *Watcher) toEndpoints(slices map[string]*discoveryv1.EndpointSlice) []gogrpcpool.Endpoint {
+	seen := map[string]bool{}
+	eps := []gogrpcpool.Endpoint{}
+
+	for _, slice := range slices {
+		port, ok := w.pickPort(slice)
+		if !ok {
+			continue
+		}
+
+		for _, ep := range slice.Endpoints {
+			if !usable(ep.Conditions) {
+				continue
+			}
+
+			meta := map[string]string{}
+			if ep.Zone != nil {
+				meta["zone"] = *ep.Zone
+			}
+			if ep.NodeName != nil {
+				meta["node"] = *ep.NodeName
+			}
+			if ep.TargetRef != nil && ep.TargetRef.Kind == "Pod" {
+				meta["pod"] = ep.TargetRef.Name
+			}
+
+			for _, addr := range ep.Addresses {
+				target := net.JoinHostPort(addr, strconv.Itoa(int(port)))
+				if seen[target] {
+					continue
+				}
+				seen[target] = true
+
+				eps = append(eps, gogrpcpool.Endpoint{Addr: target, Metadata: meta})
+			}
+		}
+	}
+
+	sort.Slice(eps, func(i, j int) bool { return eps[i].Addr < eps[j].Addr })
+	return eps
+}
+
+// 选取 EndpointSlice 上使用的端口
+func (w *Watcher) pickPort(slice *discoveryv1.EndpointSlice) (int32, bool) {
+	for _, p := range slice.Ports {
+		if p.Port == nil {
+			continue
+		}
+		if w.opts.PortName == "" || p.Name != nil && *p.Name == w.opts.PortName {
+			return *p.Port, true
+		}
+	}
+	return 0, false
+}
+
+// 判断端点是否可以承接新的连接
+// 1. 正在终止的 pod 即使仍在 serving 也不再使用，使其上的连接被排空
+// 2. 未终止的 pod 其 ready 与 serving 相同，优先使用 ready
+// 3. ready 未设置时使用 serving，两者都未设置时按照 ready 处理
+func usable(cond discoveryv1.EndpointConditions) bool {
+	if cond.Terminating != nil && *cond.Terminating {
+		return false
+	}
+	if cond.Ready != nil {
+		return *cond.Ready
+	}
+	return cond.Serving == nil || *cond.Serving
+}
@@ -0,0 +1,244 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// EndpointSlice 上的一个端点
+type endpoint struct {
+	addr        string
+	ready       *bool
+	serving     *bool
+	terminating bool
+	zone        string
+}
+
+// 构造 greeter 服务的 EndpointSlice
+func slice(name string, eps ...endpoint) *discoveryv1.EndpointSlice {
+	s := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: "greeter"},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{
+			{Name: ptr("metrics"), Port: ptr(int32(9100))},
+			{Name: ptr("grpc"), Port: ptr(int32(50051))},
+		},
+	}
+
+	for _, ep := range eps {
+		e := discoveryv1.Endpoint{
+			Addresses: []string{ep.addr},
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:       ep.ready,
+				Serving:     ep.serving,
+				Terminating: ptr(ep.terminating),
+			},
+			NodeName:  ptr("node-" + ep.addr),
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "pod-" + ep.addr},
+		}
+		if ep.zone != "" {
+			e.Zone = ptr(ep.zone)
+		}
+		s.Endpoints = append(s.Endpoints, e)
+	}
+	return s
+}
+
+// 使用 fake clientset 运行 Watch
+// 1. 每次 watch 都返回一个新的 FakeWatcher，由测试推送事件或关闭
+// 2. update 的结果推入返回的通道
+type harness struct {
+	t        *testing.T
+	client   *fake.Clientset
+	watchers chan *watch.FakeWatcher
+	updates  chan []gogrpcpool.Endpoint
+	cancel   context.CancelFunc
+	done     chan error
+}
+
+func start(t *testing.T, opts Options, objects ...runtime.Object) *harness {
+	t.Helper()
+
+	h := &harness{
+		t:        t,
+		client:   fake.NewSimpleClientset(objects...),
+		watchers: make(chan *watch.FakeWatcher, 10),
+		updates:  make(chan []gogrpcpool.Endpoint, 10),
+		done:     make(chan error, 1),
+	}
+	h.client.PrependWatchReactor("endpointslices", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		w := watch.NewFake()
+		h.watchers <- w
+		return true, w, nil
+	})
+
+	opts.Client = h.client
+	opts.Service = "greeter"
+	opts.Backoff = time.Millisecond
+	opts.OnError = func(err error) { t.Errorf("unexpected error: %v", err) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	go func() {
+		h.done <- New(opts).Watch(ctx, func(eps []gogrpcpool.Endpoint) { h.updates <- eps })
+	}()
+
+	t.Cleanup(func() {
+		h.cancel()
+		if err := <-h.done; err != context.Canceled {
+			t.Errorf("Watch returned %v, want context.Canceled", err)
+		}
+	})
+	return h
+}
+
+// 等待下一个 watch
+func (h *harness) watcher() *watch.FakeWatcher {
+	h.t.Helper()
+	select {
+	case w := <-h.watchers:
+		return w
+	case <-time.After(5 * time.Second):
+		h.t.Fatal("timed out waiting for watch")
+		return nil
+	}
+}
+
+// 等待下一次 update，并校验端点地址
+func (h *harness) expect(addrs ...string) []gogrpcpool.Endpoint {
+	h.t.Helper()
+	select {
+	case eps := <-h.updates:
+		got := []string{}
+		for _, ep := range eps {
+			got = append(got, ep.Addr)
+		}
+		if len(got) != len(addrs) {
+			h.t.Fatalf("endpoints = %v, want %v", got, addrs)
+		}
+		for i := range addrs {
+			if got[i] != addrs[i] {
+				h.t.Fatalf("endpoints = %v, want %v", got, addrs)
+			}
+		}
+		return eps
+	case <-time.After(5 * time.Second):
+		h.t.Fatalf("timed out waiting for endpoints %v", addrs)
+		return nil
+	}
+}
+
+// 确认没有多余的 update
+func (h *harness) expectNone() {
+	h.t.Helper()
+	select {
+	case eps := <-h.updates:
+		h.t.Fatalf("unexpected update %v", eps)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// list 请求的次数
+func (h *harness) lists() int {
+	n := 0
+	for _, action := range h.client.Actions() {
+		if action.GetVerb() == "list" {
+			n += 1
+		}
+	}
+	return n
+}
+
+func TestWatchEvents(t *testing.T) {
+	h := start(t, Options{PortName: "grpc"}, slice("greeter-a", endpoint{addr: "10.0.0.1", zone: "z1"}))
+
+	eps := h.expect("10.0.0.1:50051")
+	if eps[0].Metadata["zone"] != "z1" || eps[0].Metadata["node"] != "node-10.0.0.1" || eps[0].Metadata["pod"] != "pod-10.0.0.1" {
+		t.Fatalf("metadata = %v", eps[0].Metadata)
+	}
+	w := h.watcher()
+
+	w.Add(slice("greeter-b", endpoint{addr: "10.0.0.2"}))
+	h.expect("10.0.0.1:50051", "10.0.0.2:50051")
+
+	w.Modify(slice("greeter-b", endpoint{addr: "10.0.0.2"}, endpoint{addr: "10.0.0.3"}))
+	h.expect("10.0.0.1:50051", "10.0.0.2:50051", "10.0.0.3:50051")
+
+	// 内容不变的修改不会触发 update
+	w.Modify(slice("greeter-b", endpoint{addr: "10.0.0.2"}, endpoint{addr: "10.0.0.3"}))
+	h.expectNone()
+
+	w.Delete(slice("greeter-a"))
+	h.expect("10.0.0.2:50051", "10.0.0.3:50051")
+}
+
+func TestWatchConditions(t *testing.T) {
+	h := start(t, Options{}, slice("greeter-a",
+		endpoint{addr: "10.0.0.1"},
+		endpoint{addr: "10.0.0.2", ready: ptr(true)},
+		endpoint{addr: "10.0.0.3", ready: ptr(false)},
+		endpoint{addr: "10.0.0.4", serving: ptr(true)},
+		endpoint{addr: "10.0.0.5", serving: ptr(false)},
+		endpoint{addr: "10.0.0.6", ready: ptr(false), serving: ptr(true)},
+	))
+
+	// 未设置 ready 时使用 serving，设置了 ready 时以 ready 为准
+	h.expect("10.0.0.1:9100", "10.0.0.2:9100", "10.0.0.4:9100")
+	w := h.watcher()
+
+	// 正在终止的 pod 即使 ready 或 serving 也被移除
+	w.Modify(slice("greeter-a",
+		endpoint{addr: "10.0.0.1", ready: ptr(true), terminating: true},
+		endpoint{addr: "10.0.0.4", serving: ptr(true), terminating: true},
+		endpoint{addr: "10.0.0.2", ready: ptr(true)},
+	))
+	h.expect("10.0.0.2:9100")
+}
+
+func TestWatchPortName(t *testing.T) {
+	noGRPC := slice("greeter-b", endpoint{addr: "10.0.0.2"})
+	noGRPC.Ports = noGRPC.Ports[:1]
+
+	h := start(t, Options{PortName: "grpc"}, slice("greeter-a", endpoint{addr: "10.0.0.1"}), noGRPC)
+
+	// 没有 grpc 端口的 EndpointSlice 被忽略
+	h.expect("10.0.0.1:50051")
+}
+
+func TestWatchRelist(t *testing.T) {
+	h := start(t, Options{}, slice("greeter-a", endpoint{addr: "10.0.0.1"}))
+
+	h.expect("10.0.0.1:9100")
+	w := h.watcher()
+
+	// watch 期间错过的变化在重新 list 后被发现
+	_, err := h.client.DiscoveryV1().EndpointSlices("default").Create(context.Background(), slice("greeter-b", endpoint{addr: "10.0.0.2"}), metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Stop()
+
+	h.watcher()
+	h.expect("10.0.0.1:9100", "10.0.0.2:9100")
+	if n := h.lists(); n != 2 {
+		t.Fatalf("got %d lists, want 2", n)
+	}
+}
@@ -0,0 +1,28 @@
+package gogrpcpool
+
+import "context"
+
+// 服务端点
+// 1. Addr 为 grpc 拨号地址
+// 2. Weight 为端点的权重，新建连接时按照 连接数/权重 最小的端点优先，<= 0 时按 1 处理
+// 3. Metadata 为端点的附加信息，例如 zone、region 等
+type Endpoint struct {
+	Addr     string            `json:"addr" yaml:"addr"`
+	Weight   int32             `json:"weight,omitempty" yaml:"weight,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// 端点权重
+func (e Endpoint) weight() int32 {
+	if e.Weight <= 0 {
+		return 1
+	}
+	return e.Weight
+}
+
+// 端点发现
+// 1. Watch 应当阻塞运行，直到 ctx 结束或者发生不可恢复的错误
+// 2. 每当端点集合发生变化时，调用 update 并传入完整的端点列表
+type Discovery interface {
+	Watch(ctx context.Context, update func([]Endpoint)) error
+}
@@ -1,6 +1,9 @@
 package gogrpcpool
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrConnTooManyReference = errors.New("connection too many reference")
@@ -8,4 +11,22 @@ var (
 	ErrTargetNotAvailable   = errors.New("target not available")
 	ErrNoConnAvailable      = errors.New("no connection available")
 	ErrWaitConnReadyTimeout = errors.New("wait connection ready timeout")
+	ErrPoolClosed           = errors.New("pool is closed")
+	ErrConnNotPooled        = errors.New("connection does not belong to a pool")
 )
+
+// 关闭连接池时仍有未归还的引用
+// 1. Abandoned 为被放弃的引用数，这些引用对应的连接已被强制关闭
+// 2. Err 为导致等待结束的 ctx 错误
+type CloseError struct {
+	Abandoned int32
+	Err       error
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("pool closed with %d abandoned leases: %v", e.Abandoned, e.Err)
+}
+
+func (e *CloseError) Unwrap() error {
+	return e.Err
+}
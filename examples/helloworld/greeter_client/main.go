@@ -24,7 +24,7 @@ var (
 )
 
 func init() {
-	grpcConnPool = grpcpool.NewPool(grpcpool.Options{
+	pool, err := grpcpool.NewPool(grpcpool.Options{
 		Debug:            true,
 		DescribeDuration: time.Second * 1,
 		CloseWait:        time.Second * 20,
@@ -38,6 +38,11 @@ func init() {
 		MaxRefs:          10,
 		NewConnRate:      2,
 	})
+	if err != nil {
+		log.Fatalf("could not create pool: %v", err)
+	}
+
+	grpcConnPool = pool
 	grpcConnPool.Run()
 }
 
@@ -63,7 +68,14 @@ func sayHello(pool *grpcpool.Pool, n int, tm time.Duration) {
 }
 
 func main() {
-	defer grpcConnPool.Close()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		defer cancel()
+
+		if err := grpcConnPool.Close(ctx); err != nil {
+			log.Printf("close pool: %v", err)
+		}
+	}()
 	flag.Parse()
 
 	for {
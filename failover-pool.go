@@ -0,0 +1,251 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+var ErrNoFailoverTarget = errors.New("no failover target available")
+
+type FailoverOptions struct {
+	AcquireBudget    time.Duration // 在单个目标上等待连接就绪的时间预算，超时后尝试下一个目标，默认 50ms
+	FailureThreshold int32         // 当前目标连续失败多少次后熔断并切换到下一个目标，默认 3
+	ProbeInterval    time.Duration // 探测更高优先级目标是否恢复的周期，默认 1s
+	FailbackAfter    time.Duration // 更高优先级目标持续恢复多久之后回切，默认 10s
+
+	OnTransition func(FailoverEvent) // 每次切换目标时的回调，在释放锁之后调用，可以访问 FailoverPool
+}
+
+// 目标切换事件
+type FailoverEvent struct {
+	From   int    // 切换前的目标下标
+	To     int    // 切换后的目标下标
+	Reason string // 切换原因
+	At     time.Time
+}
+
+// 主备切换的连接池
+// 1. 包装按优先级排列的多个 Pool，取连接时优先使用当前目标，在预算时间内没有取到连接则依次尝试更低优先级的目标
+// 2. 当前目标连续失败达到阈值时熔断，切换到下一个目标
+// 3. 后台周期性探测更高优先级的目标，持续恢复超过 FailbackAfter 后回切
+// 4. FailoverPool 不负责各个 Pool 的启动和关闭
+type FailoverPool struct {
+	sync.Mutex
+
+	opts  FailoverOptions
+	pools []*Pool
+
+	active      int         // 当前目标的下标
+	failures    []int32     // 各目标的连续失败次数
+	recoveredAt []time.Time // 更高优先级目标探测恢复的起始时间
+
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// 实例化主备切换连接池，pools 按照优先级从高到低排列
+func NewFailoverPool(opts FailoverOptions, pools ...*Pool) (*FailoverPool, error) {
+	if len(pools) == 0 {
+		return nil, ErrNoFailoverTarget
+	}
+
+	if opts.AcquireBudget <= time.Duration(0) {
+		opts.AcquireBudget = time.Millisecond * 50
+	}
+
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = 3
+	}
+
+	if opts.ProbeInterval <= time.Duration(0) {
+		opts.ProbeInterval = time.Second
+	}
+
+	if opts.FailbackAfter <= time.Duration(0) {
+		opts.FailbackAfter = time.Second * 10
+	}
+
+	return &FailoverPool{
+		opts:        opts,
+		pools:       pools,
+		failures:    make([]int32, len(pools)),
+		recoveredAt: make([]time.Time, len(pools)),
+		stop:        make(chan struct{}),
+	}, nil
+}
+
+// 启动回切探测
+func (fp *FailoverPool) Run() {
+	fp.wg.Add(1)
+	go func() {
+		defer fp.wg.Done()
+		fp.probeManager()
+	}()
+}
+
+// 停止回切探测，等待探测的 goroutine 退出
+func (fp *FailoverPool) Close() {
+	fp.once.Do(func() {
+		close(fp.stop)
+	})
+	fp.wg.Wait()
+}
+
+// 当前目标的下标
+func (fp *FailoverPool) Active() int {
+	fp.Lock()
+	defer fp.Unlock()
+	return fp.active
+}
+
+// 寻求一个可用的连接
+// 1. 从当前目标开始依次尝试，每个目标最多等待 AcquireBudget，最后一个目标使用剩余的全部时间
+// 2. 总的等待时间不超过 d
+func (fp *FailoverPool) Acquire(d time.Duration) (*Conn, error) {
+	deadline := time.Now().Add(d)
+	err := ErrNoFailoverTarget
+
+	for i := fp.Active(); i < len(fp.pools); i++ {
+		remain := time.Until(deadline)
+		if remain <= time.Duration(0) {
+			break
+		}
+
+		wait := remain
+		if i < len(fp.pools)-1 && fp.opts.AcquireBudget < wait {
+			wait = fp.opts.AcquireBudget
+		}
+
+		var conn *Conn
+		if conn, err = fp.pools[i].Acquire(wait); err == nil {
+			fp.succeed(i)
+			return conn, nil
+		}
+		fp.fail(i)
+	}
+	return nil, err
+}
+
+// 释放连接，连接会被归还到它所属的连接池
+// 1. 不属于任何连接池的连接返回 ErrConnNotPooled
+func (fp *FailoverPool) Release(conn *Conn) error {
+	return releaseToPool(conn)
+}
+
+// 记录目标取连接成功
+func (fp *FailoverPool) succeed(i int) {
+	fp.Lock()
+	defer fp.Unlock()
+	fp.failures[i] = 0
+}
+
+// 记录目标取连接失败，当前目标连续失败达到阈值时切换到下一个目标
+func (fp *FailoverPool) fail(i int) {
+	fp.Lock()
+	fp.failures[i] += 1
+	if i != fp.active || i >= len(fp.pools)-1 || fp.failures[i] < fp.opts.FailureThreshold {
+		fp.Unlock()
+		return
+	}
+	event := fp.transition(i+1, "circuit open")
+	fp.Unlock()
+
+	fp.notify(event)
+}
+
+// 切换当前目标，需在持有锁的情况下调用，返回的事件需在释放锁之后通过 notify 回调
+func (fp *FailoverPool) transition(to int, reason string) FailoverEvent {
+	from := fp.active
+	fp.active = to
+	fp.failures[to] = 0
+	for i := range fp.recoveredAt {
+		fp.recoveredAt[i] = time.Time{}
+	}
+	return FailoverEvent{From: from, To: to, Reason: reason, At: time.Now()}
+}
+
+// 回调切换事件，不能在持有锁的情况下调用，回调中可能会访问 FailoverPool
+func (fp *FailoverPool) notify(event FailoverEvent) {
+	if fp.opts.OnTransition != nil {
+		fp.opts.OnTransition(event)
+	}
+}
+
+// 回切探测管理
+func (fp *FailoverPool) probeManager() {
+	tricker := time.NewTicker(fp.opts.ProbeInterval)
+	defer tricker.Stop()
+
+	for {
+		select {
+		case <-fp.stop:
+			return
+		case <-tricker.C:
+			fp.probeHigher()
+		}
+	}
+}
+
+// 探测比当前目标优先级更高的目标，持续恢复超过 FailbackAfter 的最高优先级目标将被回切
+func (fp *FailoverPool) probeHigher() {
+	active := fp.Active()
+	for i := 0; i < active; i++ {
+		ok := fp.probe(fp.pools[i])
+
+		fp.Lock()
+		if fp.active != active {
+			fp.Unlock()
+			return
+		}
+
+		if !ok {
+			fp.recoveredAt[i] = time.Time{}
+			fp.Unlock()
+			continue
+		}
+
+		if fp.recoveredAt[i].IsZero() {
+			fp.recoveredAt[i] = time.Now()
+		}
+		if time.Since(fp.recoveredAt[i]) >= fp.opts.FailbackAfter {
+			event := fp.transition(i, "failback")
+			fp.Unlock()
+
+			fp.notify(event)
+			return
+		}
+		fp.Unlock()
+	}
+}
+
+// 探测目标是否可用：在预算时间内取得连接，并且连接能够进入 Ready 状态
+func (fp *FailoverPool) probe(pool *Pool) bool {
+	conn, err := pool.Acquire(fp.opts.AcquireBudget)
+	if err != nil {
+		return false
+	}
+	defer pool.Release(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fp.opts.AcquireBudget)
+	defer cancel()
+
+	cc := conn.Refer()
+	for {
+		state := cc.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true
+		case connectivity.Idle:
+			cc.Connect()
+		}
+
+		if !cc.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
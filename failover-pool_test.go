@@ -0,0 +1,88 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 已关闭的连接池，取连接立即失败
+func closedPool(t *testing.T) *Pool {
+	t.Helper()
+
+	p, err := NewPool(Options{
+		Target:       "127.0.0.1:1",
+		Dopts:        []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		MaxConns:     1,
+		MaxIdleConns: 1,
+		MaxRefs:      1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestFailoverTransitionCallback(t *testing.T) {
+	events := make(chan FailoverEvent, 1)
+	var fp *FailoverPool
+
+	fp, err := NewFailoverPool(FailoverOptions{
+		AcquireBudget:    time.Millisecond,
+		FailureThreshold: 2,
+		OnTransition: func(e FailoverEvent) {
+			// 回调中访问 FailoverPool 不会死锁
+			if active := fp.Active(); active != e.To {
+				t.Errorf("Active() = %d in callback, want %d", active, e.To)
+			}
+			events <- e
+		},
+	}, closedPool(t), closedPool(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2; i++ {
+			if _, err := fp.Acquire(10 * time.Millisecond); !errors.Is(err, ErrPoolClosed) {
+				t.Errorf("Acquire = %v, want ErrPoolClosed", err)
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Acquire deadlocked")
+	}
+
+	select {
+	case e := <-events:
+		if e.From != 0 || e.To != 1 || e.Reason != "circuit open" {
+			t.Fatalf("event = %+v", e)
+		}
+	default:
+		t.Fatal("no transition")
+	}
+}
+
+func TestFailoverReleaseUnpooled(t *testing.T) {
+	fp, err := NewFailoverPool(FailoverOptions{}, closedPool(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未加入连接池的连接，例如通过 Options.Dial 建立的连接
+	if err := fp.Release(&Conn{}); !errors.Is(err, ErrConnNotPooled) {
+		t.Fatalf("Release = %v, want ErrConnNotPooled", err)
+	}
+}
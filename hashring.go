@@ -0,0 +1,88 @@
+package gogrpcpool
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+)
+
+// 每个权重单位在哈希环上的虚拟节点数
+const ringReplicas = 100
+
+// 一致性哈希环
+// 1. 每个端点按照权重在环上放置若干虚拟节点，端点变化时只有相邻区间的 key 会被重新映射
+// 2. 查找时从 key 的哈希位置开始顺时针遍历，依次返回不重复的端点，用于在首选端点满载时回退
+type hashRing struct {
+	hashes []uint64
+	addrs  []string
+	size   int // 不重复的端点数
+}
+
+type ringNode struct {
+	hash uint64
+	addr string
+}
+
+// 根据端点集合构建哈希环
+func newHashRing(eps []Endpoint) *hashRing {
+	nodes := []ringNode{}
+	for _, ep := range eps {
+		replicas := int(ep.weight()) * ringReplicas
+		for i := 0; i < replicas; i++ {
+			nodes = append(nodes, ringNode{hash: ringHash(ep.Addr + "#" + strconv.Itoa(i)), addr: ep.Addr})
+		}
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].hash == nodes[j].hash {
+			return nodes[i].addr < nodes[j].addr
+		}
+		return nodes[i].hash < nodes[j].hash
+	})
+
+	ring := &hashRing{
+		hashes: make([]uint64, len(nodes)),
+		addrs:  make([]string, len(nodes)),
+		size:   len(eps),
+	}
+	for i, node := range nodes {
+		ring.hashes[i] = node.hash
+		ring.addrs[i] = node.addr
+	}
+	return ring
+}
+
+// 按照顺时针顺序返回 key 对应的不重复端点地址，第一个为首选端点
+func (r *hashRing) lookup(key string) []string {
+	if r == nil || len(r.hashes) == 0 {
+		return nil
+	}
+
+	hash := ringHash(key)
+	start := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= hash })
+
+	seen := map[string]bool{}
+	addrs := []string{}
+	for i := 0; i < len(r.hashes) && len(addrs) < r.size; i++ {
+		addr := r.addrs[(start+i)%len(r.hashes)]
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+// 计算哈希值，fnv 的结果再经过一次混淆，使相近的字符串在环上分布得更均匀
+func ringHash(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
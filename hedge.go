@@ -0,0 +1,190 @@
+package gogrpcpool
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type HedgeOptions struct {
+	Delay          time.Duration // 发起对冲请求前的等待时间，默认 10ms；样本不足或未设置 Percentile 时使用
+	Percentile     float64       // 按照最近成功请求耗时的分位数计算等待时间，例如 0.95，为 0 时使用固定的 Delay
+	Window         int           // 计算分位数的样本数，默认 100
+	MaxAttempts    int           // 单次调用最多发起的请求数（包括第一次请求），默认 2
+	AcquireTimeout time.Duration // 对冲请求取连接的等待时间，默认 50ms；第一个请求按照调用方的 ctx 等待
+}
+
+// 对冲请求
+// 1. 在一个连接上发起请求，超过等待时间仍未完成时，在另一个不同的连接上发起相同的请求
+// 2. 取第一个成功的结果，其余请求被取消
+// 3. 每个请求都单独引用连接，遵守连接的最大引用数，请求结束后释放
+// 4. 只适用于幂等的一元请求
+type Hedger struct {
+	sync.Mutex
+
+	pool *Pool
+	opts HedgeOptions
+
+	samples []time.Duration // 最近成功请求的耗时，环形存储
+	next    int
+}
+
+// 最少需要多少个样本才按照分位数计算等待时间
+const hedgeMinSamples = 10
+
+// 实例化对冲请求
+func NewHedger(pool *Pool, opts HedgeOptions) *Hedger {
+	if opts.Delay <= time.Duration(0) {
+		opts.Delay = time.Millisecond * 10
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = 100
+	}
+
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = 2
+	}
+
+	if opts.AcquireTimeout <= time.Duration(0) {
+		opts.AcquireTimeout = time.Millisecond * 50
+	}
+
+	return &Hedger{
+		pool:    pool,
+		opts:    opts,
+		samples: []time.Duration{},
+	}
+}
+
+// 发起对冲请求
+// 1. call 需要在 ctx 被取消时尽快返回
+// 2. 第一个请求按照 ctx 等待连接，对冲请求在后台取连接，最多等待 AcquireTimeout，不会阻塞已发起的请求
+// 3. 任意一个请求成功即返回 nil，所有请求都失败时返回最后一个错误
+func (h *Hedger) Invoke(ctx context.Context, call func(ctx context.Context, cc *grpc.ClientConn) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	mu := sync.Mutex{}
+	used := map[*Conn]bool{}
+	results := make(chan hedgeResult, h.opts.MaxAttempts)
+
+	// 引用一个未使用过的连接
+	acquire := func(actx context.Context) (*Conn, error) {
+		return h.pool.acquireDirect(actx, func() (*Conn, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			conn := h.pool.referConn(func(c *Conn) bool { return !used[c] })
+			if conn != nil {
+				used[conn] = true
+			}
+			return conn, nil
+		})
+	}
+
+	// 在连接上发起请求，写入结果之前释放连接
+	attempt := func(conn *Conn) {
+		st := time.Now()
+		err := call(ctx, conn.Refer())
+		h.pool.Release(conn)
+		if err == nil {
+			h.observe(time.Since(st))
+		}
+		results <- hedgeResult{err: err, called: true}
+	}
+
+	// 发起对冲请求，取不到连接时同样写入结果
+	hedge := func() {
+		actx, acancel := context.WithTimeout(ctx, h.opts.AcquireTimeout)
+		conn, err := acquire(actx)
+		acancel()
+		if err != nil {
+			results <- hedgeResult{err: err}
+			return
+		}
+		attempt(conn)
+	}
+
+	conn, err := acquire(ctx)
+	if err != nil {
+		return err
+	}
+	go attempt(conn)
+
+	tricker := time.NewTimer(h.delay())
+	defer tricker.Stop()
+
+	var lastErr error
+	attempts, pending := 1, 1
+	for pending > 0 {
+		select {
+		case res := <-results:
+			pending -= 1
+			if res.err == nil {
+				return nil
+			}
+			// 取不到其他连接时不再对冲，继续等待已发起的请求
+			if !res.called {
+				attempts = h.opts.MaxAttempts
+				continue
+			}
+			lastErr = res.err
+		case <-tricker.C:
+			if attempts < h.opts.MaxAttempts {
+				attempts += 1
+				pending += 1
+				go hedge()
+				tricker.Reset(h.delay())
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return lastErr
+}
+
+// 一次请求的结果，called 为 false 时表示没有取到连接
+type hedgeResult struct {
+	err    error
+	called bool
+}
+
+// 计算对冲等待时间
+func (h *Hedger) delay() time.Duration {
+	if h.opts.Percentile <= 0 {
+		return h.opts.Delay
+	}
+
+	h.Lock()
+	samples := make([]time.Duration, len(h.samples))
+	copy(samples, h.samples)
+	h.Unlock()
+
+	if len(samples) < hedgeMinSamples {
+		return h.opts.Delay
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	idx := int(float64(len(samples)-1) * h.opts.Percentile)
+	if idx >= len(samples) {
+		idx = len(samples) - 1
+	}
+	return samples[idx]
+}
+
+// 记录一次成功请求的耗时
+func (h *Hedger) observe(d time.Duration) {
+	h.Lock()
+	defer h.Unlock()
+
+	if len(h.samples) < h.opts.Window {
+		h.samples = append(h.samples, d)
+		return
+	}
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % h.opts.Window
+}
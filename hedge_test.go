@@ -0,0 +1,195 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestHedgeDistinctConns(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 2
+	opts.MaxIdleConns = 2
+	opts.MaxRefs = 2
+	p := runPool(t, opts)
+
+	h := NewHedger(p, HedgeOptions{Delay: 5 * time.Millisecond, MaxAttempts: 3})
+
+	mu := sync.Mutex{}
+	seen := map[*grpc.ClientConn]int{}
+	cancelled := make(chan struct{}, 3)
+	var calls int32
+
+	err := h.Invoke(context.Background(), func(ctx context.Context, cc *grpc.ClientConn) error {
+		mu.Lock()
+		seen[cc] += 1
+		mu.Unlock()
+
+		// 第一个请求一直阻塞，由对冲请求返回结果
+		if atomic.AddInt32(&calls, 1) == 1 {
+			<-ctx.Done()
+			cancelled <- struct{}{}
+			return ctx.Err()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("losing attempt was not cancelled")
+	}
+
+	// 两个连接都还有空余的引用，但同一次调用不会在同一个连接上发起两个请求
+	mu.Lock()
+	defer mu.Unlock()
+	if len(seen) != 2 || atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("calls = %d on %d conns, want 2 on 2 distinct conns", calls, len(seen))
+	}
+	for _, n := range seen {
+		if n != 1 {
+			t.Fatalf("conn used %d times, want 1", n)
+		}
+	}
+}
+
+func TestHedgeMaxRefs(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 3
+	opts.MaxIdleConns = 3
+	opts.MaxRefs = 1
+	p := runPool(t, opts)
+
+	// 被占满的连接不会被对冲请求引用
+	held, err := p.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Release(held)
+
+	h := NewHedger(p, HedgeOptions{Delay: time.Millisecond, MaxAttempts: 3, AcquireTimeout: 20 * time.Millisecond})
+
+	inflight := make(chan struct{})
+	release := make(chan struct{})
+	var calls int32
+	go func() {
+		<-inflight
+		// 两个请求都在进行中时，每个连接都只被引用了一次
+		for _, cs := range p.Stats().Conns {
+			if cs.Ref != 1 {
+				t.Errorf("conn %d ref = %d, want 1", cs.ID, cs.Ref)
+			}
+		}
+		close(release)
+	}()
+
+	err = h.Invoke(context.Background(), func(ctx context.Context, cc *grpc.ClientConn) error {
+		if cc == held.Refer() {
+			t.Error("hedge used a conn at MaxRefs")
+		}
+		if atomic.AddInt32(&calls, 1) == 2 {
+			close(inflight)
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return errors.New("unavailable")
+	})
+	if err == nil || err.Error() != "unavailable" {
+		t.Fatalf("Invoke = %v, want the call error", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Fatalf("calls = %d, want 2", n)
+	}
+
+	// 请求结束后引用都被释放，只剩 held
+	if stats := p.Stats(); stats.RefCount != 1 {
+		t.Fatalf("refs = %d, want 1", stats.RefCount)
+	}
+}
+
+func TestHedgeDoesNotBlockOnAcquire(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	p := runPool(t, opts)
+
+	// 唯一的连接被第一个请求使用，对冲请求取不到连接也不影响第一个请求返回
+	h := NewHedger(p, HedgeOptions{Delay: time.Millisecond, AcquireTimeout: 500 * time.Millisecond})
+
+	st := time.Now()
+	err := h.Invoke(context.Background(), func(ctx context.Context, cc *grpc.ClientConn) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost := time.Since(st); cost > 200*time.Millisecond {
+		t.Fatalf("Invoke took %v, want about 20ms", cost)
+	}
+}
+
+func TestHedgeFirstAcquireWaitsForCtx(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 1
+	p := runPool(t, opts)
+
+	held, err := p.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(100*time.Millisecond, func() { p.Release(held) })
+
+	// 第一个请求按照调用方的 ctx 等待连接，而不是 AcquireTimeout
+	h := NewHedger(p, HedgeOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = h.Invoke(ctx, func(ctx context.Context, cc *grpc.ClientConn) error { return nil })
+	if err != nil {
+		t.Fatalf("Invoke = %v, want nil", err)
+	}
+}
+
+func TestHedgeDelayPercentile(t *testing.T) {
+	h := NewHedger(nil, HedgeOptions{Delay: 7 * time.Millisecond, Percentile: 0.5, Window: 10})
+
+	// 样本不足时使用固定的 Delay
+	for i := 1; i < hedgeMinSamples; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if d := h.delay(); d != 7*time.Millisecond {
+		t.Fatalf("delay = %v with too few samples, want 7ms", d)
+	}
+
+	// 只保留最近 Window 个样本：11ms ~ 20ms
+	for i := hedgeMinSamples; i <= 20; i++ {
+		h.observe(time.Duration(i) * time.Millisecond)
+	}
+	if d := h.delay(); d != 15*time.Millisecond {
+		t.Fatalf("delay = %v, want p50 of the last 10 samples 15ms", d)
+	}
+
+	h.opts.Percentile = 1
+	if d := h.delay(); d != 20*time.Millisecond {
+		t.Fatalf("delay = %v, want p100 20ms", d)
+	}
+
+	// 未设置 Percentile 时使用固定的 Delay
+	h.opts.Percentile = 0
+	if d := h.delay(); d != 7*time.Millisecond {
+		t.Fatalf("delay = %v, want 7ms", d)
+	}
+}
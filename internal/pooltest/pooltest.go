@@ -0,0 +1,40 @@
+// 测试共用的辅助函数，供连接池及其扩展包的测试使用
+package pooltest
+
+import (
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+// 丢弃所有输出的日志
+type NopLogger struct{}
+
+func (NopLogger) Debug(string, ...any) {}
+func (NopLogger) Info(string, ...any)  {}
+func (NopLogger) Warn(string, ...any)  {}
+func (NopLogger) Error(string, ...any) {}
+
+// 启动一个本地 grpc 服务，测试结束时停止
+func Serve(t testing.TB) string {
+	t.Helper()
+
+	addr, _ := ServeAt(t, "127.0.0.1:0")
+	return addr
+}
+
+// 在指定地址启动一个本地 grpc 服务，测试结束时停止
+func ServeAt(t testing.TB, addr string) (string, *grpc.Server) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := grpc.NewServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String(), srv
+}
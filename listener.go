@@ -0,0 +1,27 @@
+package gogrpcpool
+
+import "time"
+
+// 连接池生命周期事件监听
+// 1. 回调在连接池内部同步执行，部分回调执行时持有连接池的锁，回调中不能再调用连接池的方法，耗时操作需要异步处理
+// 2. 只关心部分事件时，可以嵌入 NopEventListener 后覆盖需要的方法
+type EventListener interface {
+	OnConnDialed(conn *Conn, cost time.Duration)             // 新连接拨号成功
+	OnDialFailed(addr string, cost time.Duration, err error) // 拨号失败
+	OnConnClosing(conn *Conn)                                // 连接被标记为关闭中
+	OnConnClosed(conn *Conn)                                 // 连接被关闭
+	OnAcquire(conn *Conn, wait time.Duration)                // 取得连接
+	OnAcquireTimeout(wait time.Duration)                     // 等待连接就绪超时
+	OnRelease(conn *Conn)                                    // 连接被释放
+}
+
+// 不做任何处理的事件监听
+type NopEventListener struct{}
+
+func (NopEventListener) OnConnDialed(*Conn, time.Duration)         {}
+func (NopEventListener) OnDialFailed(string, time.Duration, error) {}
+func (NopEventListener) OnConnClosing(*Conn)                       {}
+func (NopEventListener) OnConnClosed(*Conn)                        {}
+func (NopEventListener) OnAcquire(*Conn, time.Duration)            {}
+func (NopEventListener) OnAcquireTimeout(time.Duration)            {}
+func (NopEventListener) OnRelease(*Conn)                           {}
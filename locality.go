@@ -0,0 +1,55 @@
+package gogrpcpool
+
+// 端点元数据中表示位置的键
+const (
+	MetadataZone   = "zone"
+	MetadataRegion = "region"
+)
+
+// 调用方所在的位置
+// 1. 配置后连接池优先使用同一位置的端点上的连接
+// 2. 仅当本地连接的引用都达到最大或者本地连接都不健康时，才溢出到其他位置的连接
+type Locality struct {
+	Region string
+	Zone   string
+}
+
+// 是否未配置位置
+func (l Locality) isZero() bool {
+	return l.Region == "" && l.Zone == ""
+}
+
+// 判断端点是否与调用方处于同一位置
+// 1. 配置了 Zone 时按照 Zone 匹配，否则按照 Region 匹配
+func (l Locality) match(ep Endpoint) bool {
+	if l.Zone != "" {
+		return ep.Metadata[MetadataZone] == l.Zone
+	}
+	if l.Region != "" {
+		return ep.Metadata[MetadataRegion] == l.Region
+	}
+	return false
+}
+
+// 端点所在位置的名称，优先使用 zone，其次是 region
+func localityName(ep Endpoint) string {
+	if zone := ep.Metadata[MetadataZone]; zone != "" {
+		return zone
+	}
+	if region := ep.Metadata[MetadataRegion]; region != "" {
+		return region
+	}
+	return "unknown"
+}
+
+// 统计一个连接，关闭中的连接不计入容量
+func (s *LocalityStats) add(c *Conn, cs ConnStats) {
+	s.Conns += 1
+	s.Refs += cs.Ref
+	if !c.healthy() {
+		s.Unhealthy += 1
+	}
+	if !c.closing.Load() {
+		s.Capacity += cs.MaxRef
+	}
+}
@@ -0,0 +1,154 @@
+package gogrpcpool
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// 本地位置为 zone-a 的连接池，端点按照 remote、local 的顺序发现
+func localityPool(t *testing.T, remote, local string) *Pool {
+	t.Helper()
+
+	opts := testOptions("")
+	opts.Discovery = manualDiscovery{}
+	opts.Locality = Locality{Zone: "zone-a"}
+	opts.MaxConns = 2
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 2
+	p := runPool(t, opts)
+
+	p.updateEndpoints([]Endpoint{
+		{Addr: remote, Metadata: map[string]string{MetadataZone: "zone-b"}},
+		{Addr: local, Metadata: map[string]string{MetadataZone: "zone-a"}},
+	})
+	return p
+}
+
+// 端点上的连接
+func connOn(t *testing.T, p *Pool, addr string) *Conn {
+	t.Helper()
+
+	p.RLock()
+	defer p.RUnlock()
+	for _, conn := range p.conns {
+		if conn.endpoint.Addr == addr {
+			return conn
+		}
+	}
+	t.Fatalf("no conn on %s", addr)
+	return nil
+}
+
+// 新建连接时选取的端点
+func pickedEndpoint(t *testing.T, p *Pool) string {
+	t.Helper()
+
+	p.Lock()
+	defer p.Unlock()
+	ep, err := p.pickEndpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ep.Addr
+}
+
+// 位置的统计
+func localityStats(t *testing.T, p *Pool, name string) LocalityStats {
+	t.Helper()
+
+	for _, ls := range p.Stats().Localities {
+		if ls.Name == name {
+			return ls
+		}
+	}
+	t.Fatalf("no locality %s in stats", name)
+	return LocalityStats{}
+}
+
+// 一个拒绝连接的地址
+func deadAddr(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+	return addr
+}
+
+func TestLocalityPreferLocal(t *testing.T) {
+	remote, local := serve(t), serve(t)
+	p := localityPool(t, remote, local)
+
+	// 两个端点上的连接数相同时，新建连接优先选取本地端点
+	if addr := pickedEndpoint(t, p); addr != local {
+		t.Fatalf("picked endpoint %s, want local %s", addr, local)
+	}
+
+	// 本地连接就绪时优先被取用，直到引用数达到最大
+	localConn := connOn(t, p, local)
+	held := []*Conn{}
+	for i := 0; i < 2; i++ {
+		eventually(t, "local conn not ready", localConn.readying.Load)
+		conn, err := p.Acquire(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn != localConn {
+			t.Fatalf("acquire %d got conn on %s, want local", i, conn.endpoint.Addr)
+		}
+		held = append(held, conn)
+	}
+
+	// 本地连接满载后溢出到其他位置的连接
+	conn, err := p.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.endpoint.Addr != remote {
+		t.Fatalf("spill-over conn on %s, want remote %s", conn.endpoint.Addr, remote)
+	}
+	held = append(held, conn)
+
+	ls := localityStats(t, p, "zone-a")
+	if !ls.Local || ls.Conns != 1 || ls.Refs != 2 || ls.Capacity != 2 || ls.Unhealthy != 0 {
+		t.Fatalf("local stats = %+v", ls)
+	}
+	rs := localityStats(t, p, "zone-b")
+	if rs.Local || rs.Conns != 1 || rs.Refs != 1 || rs.Capacity != 2 {
+		t.Fatalf("remote stats = %+v", rs)
+	}
+
+	for _, conn := range held {
+		p.Release(conn)
+	}
+}
+
+func TestLocalityUnhealthySpillOver(t *testing.T) {
+	remote, local := serve(t), deadAddr(t)
+	p := localityPool(t, remote, local)
+
+	localConn := connOn(t, p, local)
+	eventually(t, "local conn did not fail", func() bool { return !localConn.healthy() })
+
+	// 本地连接都不健康时，新建连接和取连接都溢出到其他位置
+	if addr := pickedEndpoint(t, p); addr != remote {
+		t.Fatalf("picked endpoint %s, want remote %s", addr, remote)
+	}
+
+	conn, err := p.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Release(conn)
+	if conn.endpoint.Addr != remote {
+		t.Fatalf("acquired conn on %s, want remote %s", conn.endpoint.Addr, remote)
+	}
+
+	if ls := localityStats(t, p, "zone-a"); !ls.Local || ls.Unhealthy != 1 || ls.Refs != 0 {
+		t.Fatalf("local stats = %+v", ls)
+	}
+}
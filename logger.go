@@ -0,0 +1,108 @@
+package gogrpcpool
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// 日志接口，*slog.Logger 可以直接作为实现
+// 1. msg 为日志内容，args 为交替排列的键值对，例如 "addr", "127.0.0.1:50051"
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// 默认日志，输出 info 及以上级别到标准错误
+var defaultLogger Logger = NewStdLogger(os.Stderr, false)
+
+// 默认日志
+func DefaultLogger() Logger {
+	return defaultLogger
+}
+
+// 基于标准库 log.Logger 的日志实现，以 key=value 的形式输出键值对
+// 1. debug 为 false 时不输出 debug 级别的日志
+func NewStdLogger(w io.Writer, debug bool) Logger {
+	return &stdLogger{
+		logger: log.New(w, "", log.LstdFlags),
+		debug:  debug,
+	}
+}
+
+type stdLogger struct {
+	logger *log.Logger
+	debug  bool
+}
+
+func (l *stdLogger) Debug(msg string, args ...any) {
+	if l.debug {
+		l.output("DEBUG", msg, args)
+	}
+}
+
+func (l *stdLogger) Info(msg string, args ...any) {
+	l.output("INFO", msg, args)
+}
+
+func (l *stdLogger) Warn(msg string, args ...any) {
+	l.output("WARN", msg, args)
+}
+
+func (l *stdLogger) Error(msg string, args ...any) {
+	l.output("ERROR", msg, args)
+}
+
+// 输出一行日志
+func (l *stdLogger) output(level string, msg string, args []any) {
+	b := strings.Builder{}
+	b.WriteString(level)
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%v", args[i], args[i+1])
+		} else {
+			fmt.Fprintf(&b, " %v", args[i])
+		}
+	}
+	l.logger.Output(3, b.String())
+}
+
+// 带有固定字段的日志，每条日志都会附加这些字段
+type fieldLogger struct {
+	logger Logger
+	fields []any
+}
+
+// 为日志附加固定字段
+func withFields(logger Logger, fields ...any) Logger {
+	return &fieldLogger{logger: logger, fields: fields}
+}
+
+func (l *fieldLogger) Debug(msg string, args ...any) {
+	l.logger.Debug(msg, l.with(args)...)
+}
+
+func (l *fieldLogger) Info(msg string, args ...any) {
+	l.logger.Info(msg, l.with(args)...)
+}
+
+func (l *fieldLogger) Warn(msg string, args ...any) {
+	l.logger.Warn(msg, l.with(args)...)
+}
+
+func (l *fieldLogger) Error(msg string, args ...any) {
+	l.logger.Error(msg, l.with(args)...)
+}
+
+// 合并固定字段和本次的字段
+func (l *fieldLogger) with(args []any) []any {
+	merged := make([]any, 0, len(l.fields)+len(args))
+	merged = append(merged, l.fields...)
+	return append(merged, args...)
+}
@@ -0,0 +1,164 @@
+// 连接池的 OpenTelemetry 指标
+//
+// Metrics 同时实现了 gogrpcpool.Recorder，需要设置到连接池的 Options.Recorder 中来记录取连接、拨号和连接关闭事件，
+// 并通过 Observe 注册需要采集状态的连接池：
+//
+//	m, err := otelmetric.New(otelmetric.Options{MeterProvider: provider})
+//	pool, err := gogrpcpool.NewPool(gogrpcpool.Options{Name: "greeter", Recorder: m, ...})
+//	m.Observe(pool)
+//
+// 导出的指标：
+//   - grpcpool.conns / grpcpool.conns.idle / grpcpool.conns.closing / grpcpool.refs：连接池状态，异步采集
+//   - grpcpool.acquires：取连接次数，按 outcome（ok/timeout/closed/error）区分
+//   - grpcpool.acquire.duration：取连接的等待时间
+//   - grpcpool.dial.duration：拨号耗时，按 outcome（ok/error）区分
+//   - grpcpool.conn.lifetime：连接从建立到关闭的存活时长
+//
+// 该包是独立的 module（github.com/biandoucheng/go-grpc-pool/metrics/otelmetric），只使用连接池的项目不会引入 OpenTelemetry SDK 依赖
+package otelmetric
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/biandoucheng/go-grpc-pool"
+
+type Options struct {
+	MeterProvider metric.MeterProvider // 默认使用全局的 MeterProvider
+}
+
+// 连接池指标
+type Metrics struct {
+	sync.RWMutex
+	pools []*gogrpcpool.Pool
+
+	conns           metric.Int64ObservableGauge
+	idleConns       metric.Int64ObservableGauge
+	closingConns    metric.Int64ObservableGauge
+	refs            metric.Int64ObservableGauge
+	acquires        metric.Int64Counter
+	acquireDuration metric.Float64Histogram
+	dialDuration    metric.Float64Histogram
+	connLifetime    metric.Float64Histogram
+
+	registration metric.Registration
+}
+
+// 实例化连接池指标，创建所有的指标并注册状态采集回调
+func New(opts Options) (*Metrics, error) {
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+	meter := opts.MeterProvider.Meter(instrumentationName)
+
+	m := &Metrics{pools: []*gogrpcpool.Pool{}}
+	var err error
+
+	if m.conns, err = meter.Int64ObservableGauge("grpcpool.conns",
+		metric.WithDescription("Number of established connections."), metric.WithUnit("{connection}")); err != nil {
+		return nil, err
+	}
+	if m.idleConns, err = meter.Int64ObservableGauge("grpcpool.conns.idle",
+		metric.WithDescription("Number of idle connections."), metric.WithUnit("{connection}")); err != nil {
+		return nil, err
+	}
+	if m.closingConns, err = meter.Int64ObservableGauge("grpcpool.conns.closing",
+		metric.WithDescription("Number of connections being closed."), metric.WithUnit("{connection}")); err != nil {
+		return nil, err
+	}
+	if m.refs, err = meter.Int64ObservableGauge("grpcpool.refs",
+		metric.WithDescription("Number of outstanding connection references."), metric.WithUnit("{reference}")); err != nil {
+		return nil, err
+	}
+	if m.acquires, err = meter.Int64Counter("grpcpool.acquires",
+		metric.WithDescription("Number of acquisitions by outcome."), metric.WithUnit("{acquisition}")); err != nil {
+		return nil, err
+	}
+	if m.acquireDuration, err = meter.Float64Histogram("grpcpool.acquire.duration",
+		metric.WithDescription("Time spent waiting to acquire a connection."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.dialDuration, err = meter.Float64Histogram("grpcpool.dial.duration",
+		metric.WithDescription("Time spent dialing a new connection."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if m.connLifetime, err = meter.Float64Histogram("grpcpool.conn.lifetime",
+		metric.WithDescription("Time between a connection being dialed and closed."), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+
+	if m.registration, err = meter.RegisterCallback(m.observe, m.conns, m.idleConns, m.closingConns, m.refs); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// 添加需要采集状态的连接池
+func (m *Metrics) Observe(pools ...*gogrpcpool.Pool) {
+	m.Lock()
+	defer m.Unlock()
+	m.pools = append(m.pools, pools...)
+}
+
+// 注销状态采集回调
+func (m *Metrics) Shutdown() error {
+	return m.registration.Unregister()
+}
+
+// 采集连接池状态
+func (m *Metrics) observe(_ context.Context, o metric.Observer) error {
+	m.RLock()
+	defer m.RUnlock()
+
+	for _, pool := range m.pools {
+		stats := pool.Stats()
+		attrs := metric.WithAttributes(
+			attribute.String("pool", stats.Name),
+			attribute.String("target", stats.Target),
+		)
+
+		o.ObserveInt64(m.conns, int64(stats.ConnCount), attrs)
+		o.ObserveInt64(m.idleConns, int64(stats.ConnIdleCount), attrs)
+		o.ObserveInt64(m.closingConns, int64(stats.ConnClosingCount), attrs)
+		o.ObserveInt64(m.refs, int64(stats.RefCount), attrs)
+	}
+	return nil
+}
+
+// 实现 gogrpcpool.Recorder
+func (m *Metrics) RecordAcquire(pool string, wait time.Duration, outcome gogrpcpool.AcquireOutcome) {
+	attrs := metric.WithAttributes(
+		attribute.String("pool", pool),
+		attribute.String("outcome", string(outcome)),
+	)
+	m.acquires.Add(context.Background(), 1, attrs)
+	m.acquireDuration.Record(context.Background(), wait.Seconds(), attrs)
+}
+
+// 实现 gogrpcpool.Recorder
+func (m *Metrics) RecordDial(pool string, addr string, cost time.Duration, err error) {
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	m.dialDuration.Record(context.Background(), cost.Seconds(), metric.WithAttributes(
+		attribute.String("pool", pool),
+		attribute.String("addr", addr),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// 实现 gogrpcpool.Recorder
+func (m *Metrics) RecordConnClosed(pool string, addr string, lifetime time.Duration) {
+	m.connLifetime.Record(context.Background(), lifetime.Seconds(), metric.WithAttributes(
+		attribute.String("pool", pool),
+		attribute.String("addr", addr),
+	))
+}
@@ -0,0 +1,158 @@
+package otelmetric
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"github.com/biandoucheng/go-grpc-pool/internal/pooltest"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 采集一次所有指标
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	t.Helper()
+
+	rm := metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+// 按属性查找数据点
+func point[N int64 | float64](t *testing.T, points []metricdata.DataPoint[N], attrs ...attribute.KeyValue) metricdata.DataPoint[N] {
+	t.Helper()
+
+	set := attribute.NewSet(attrs...)
+	for _, p := range points {
+		if p.Attributes.Equals(&set) {
+			return p
+		}
+	}
+	t.Fatalf("no data point with attributes %v in %v", attrs, points)
+	return metricdata.DataPoint[N]{}
+}
+
+// 按属性查找直方图数据点
+func histogram(t *testing.T, points []metricdata.HistogramDataPoint[float64], attrs ...attribute.KeyValue) metricdata.HistogramDataPoint[float64] {
+	t.Helper()
+
+	set := attribute.NewSet(attrs...)
+	for _, p := range points {
+		if p.Attributes.Equals(&set) {
+			return p
+		}
+	}
+	t.Fatalf("no histogram data point with attributes %v in %v", attrs, points)
+	return metricdata.HistogramDataPoint[float64]{}
+}
+
+func TestMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	defer provider.Shutdown(context.Background())
+
+	m, err := New(Options{MeterProvider: provider})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Shutdown()
+
+	target := pooltest.Serve(t)
+	pool, err := gogrpcpool.New(target,
+		gogrpcpool.WithName("greeter"),
+		gogrpcpool.WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		gogrpcpool.WithMaxConns(1),
+		gogrpcpool.WithMaxIdleConns(1),
+		gogrpcpool.WithMaxRefs(1),
+		gogrpcpool.WithRecorder(m),
+		gogrpcpool.WithLogger(pooltest.NopLogger{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Observe(pool)
+	pool.Run()
+
+	conn, err := pool.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Acquire(10 * time.Millisecond); !errors.Is(err, gogrpcpool.ErrWaitConnReadyTimeout) {
+		t.Fatalf("Acquire = %v, want timeout", err)
+	}
+
+	poolAttrs := []attribute.KeyValue{attribute.String("pool", "greeter"), attribute.String("target", target)}
+	metrics := collect(t, reader)
+
+	// 连接池状态
+	gauges := map[string]int64{
+		"grpcpool.conns":         1,
+		"grpcpool.conns.idle":    0,
+		"grpcpool.conns.closing": 0,
+		"grpcpool.refs":          1,
+	}
+	for name, want := range gauges {
+		gauge, ok := metrics[name].(metricdata.Gauge[int64])
+		if !ok {
+			t.Fatalf("%s is %T, want gauge", name, metrics[name])
+		}
+		if got := point(t, gauge.DataPoints, poolAttrs...).Value; got != want {
+			t.Errorf("%s = %d, want %d", name, got, want)
+		}
+	}
+
+	pool.Release(conn)
+	if err := pool.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Acquire(10 * time.Millisecond); !errors.Is(err, gogrpcpool.ErrPoolClosed) {
+		t.Fatalf("Acquire = %v, want ErrPoolClosed", err)
+	}
+	m.RecordDial("greeter", "127.0.0.1:1", time.Millisecond, errors.New("refused"))
+
+	metrics = collect(t, reader)
+
+	// 取连接按 outcome 区分
+	acquires := metrics["grpcpool.acquires"].(metricdata.Sum[int64])
+	waits := metrics["grpcpool.acquire.duration"].(metricdata.Histogram[float64])
+	for _, outcome := range []gogrpcpool.AcquireOutcome{gogrpcpool.AcquireOK, gogrpcpool.AcquireTimeout, gogrpcpool.AcquireClosed} {
+		attrs := []attribute.KeyValue{attribute.String("pool", "greeter"), attribute.String("outcome", string(outcome))}
+		if got := point(t, acquires.DataPoints, attrs...).Value; got != 1 {
+			t.Errorf("acquires{outcome=%s} = %d, want 1", outcome, got)
+		}
+		if got := histogram(t, waits.DataPoints, attrs...).Count; got != 1 {
+			t.Errorf("acquire.duration{outcome=%s} count = %d, want 1", outcome, got)
+		}
+	}
+
+	// 拨号耗时按 outcome 区分
+	dials := metrics["grpcpool.dial.duration"].(metricdata.Histogram[float64])
+	if got := histogram(t, dials.DataPoints, attribute.String("pool", "greeter"), attribute.String("addr", target), attribute.String("outcome", "ok")).Count; got != 1 {
+		t.Errorf("dial.duration{outcome=ok} count = %d, want 1", got)
+	}
+	if got := histogram(t, dials.DataPoints, attribute.String("pool", "greeter"), attribute.String("addr", "127.0.0.1:1"), attribute.String("outcome", "error")).Count; got != 1 {
+		t.Errorf("dial.duration{outcome=error} count = %d, want 1", got)
+	}
+
+	// 关闭连接池时记录连接的存活时长
+	lifetimes := metrics["grpcpool.conn.lifetime"].(metricdata.Histogram[float64])
+	lifetime := histogram(t, lifetimes.DataPoints, attribute.String("pool", "greeter"), attribute.String("addr", target))
+	if lifetime.Count != 1 || lifetime.Sum <= 0 {
+		t.Errorf("conn.lifetime count = %d, sum = %v, want 1 and > 0", lifetime.Count, lifetime.Sum)
+	}
+}
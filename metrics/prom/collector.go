@@ -0,0 +1,121 @@
+// 连接池的 Prometheus 指标
+//
+// Collector 在每次被采集时读取各个连接池的 Stats 快照，导出以下指标，均带有 pool、target 标签：
+//   - grpcpool_conns / grpcpool_idle_conns / grpcpool_closing_conns / grpcpool_refs：连接数与引用数
+//   - grpcpool_acquisitions_total / grpcpool_acquire_timeouts_total：取连接成功与超时的次数
+//   - grpcpool_dials_total / grpcpool_dial_failures_total：拨号与拨号失败的次数
+//   - grpcpool_acquire_wait_seconds：取连接等待时间的分布
+//
+// 已关闭的连接池不再被采集，并从 Collector 中移除；pool、target 标签都相同的连接池只采集先添加的一个，
+// 需要为同一个 target 的多个连接池设置不同的 Options.Name。
+//
+// 使用方式：
+//
+//	prometheus.MustRegister(prom.NewCollector(pool))
+//
+// 该包是独立的 module（github.com/biandoucheng/go-grpc-pool/metrics/prom），只使用连接池的项目不会引入 prometheus client_golang 依赖
+package prom
+
+import (
+	"sync"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "grpcpool"
+
+var labels = []string{"pool", "target"}
+
+var (
+	connsDesc        = prometheus.NewDesc(namespace+"_conns", "Number of established connections.", labels, nil)
+	idleConnsDesc    = prometheus.NewDesc(namespace+"_idle_conns", "Number of idle connections.", labels, nil)
+	closingConnsDesc = prometheus.NewDesc(namespace+"_closing_conns", "Number of connections being closed.", labels, nil)
+	refsDesc         = prometheus.NewDesc(namespace+"_refs", "Number of outstanding connection references.", labels, nil)
+	acquiredDesc     = prometheus.NewDesc(namespace+"_acquisitions_total", "Total number of successful acquisitions.", labels, nil)
+	timeoutsDesc     = prometheus.NewDesc(namespace+"_acquire_timeouts_total", "Total number of acquisitions that timed out waiting for a ready connection.", labels, nil)
+	dialsDesc        = prometheus.NewDesc(namespace+"_dials_total", "Total number of dials.", labels, nil)
+	dialFailuresDesc = prometheus.NewDesc(namespace+"_dial_failures_total", "Total number of failed dials.", labels, nil)
+	waitDesc         = prometheus.NewDesc(namespace+"_acquire_wait_seconds", "Time spent waiting to acquire a connection.", labels, nil)
+)
+
+// 连接池指标采集器
+type Collector struct {
+	sync.Mutex
+	pools []*gogrpcpool.Pool
+}
+
+// 实例化采集器
+func NewCollector(pools ...*gogrpcpool.Pool) *Collector {
+	return &Collector{pools: append([]*gogrpcpool.Pool{}, pools...)}
+}
+
+// 添加需要采集的连接池
+func (c *Collector) Add(pool *gogrpcpool.Pool) {
+	c.Lock()
+	defer c.Unlock()
+	c.pools = append(c.pools, pool)
+}
+
+// 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- connsDesc
+	ch <- idleConnsDesc
+	ch <- closingConnsDesc
+	ch <- refsDesc
+	ch <- acquiredDesc
+	ch <- timeoutsDesc
+	ch <- dialsDesc
+	ch <- dialFailuresDesc
+	ch <- waitDesc
+}
+
+// 实现 prometheus.Collector
+// 1. 移除已关闭的连接池
+// 2. 标签相同的连接池只采集第一个，避免重复的指标导致采集失败
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.Lock()
+	defer c.Unlock()
+
+	pools := []*gogrpcpool.Pool{}
+	seen := map[[2]string]bool{}
+	for _, pool := range c.pools {
+		if pool.State() == gogrpcpool.StateClosed {
+			continue
+		}
+		pools = append(pools, pool)
+
+		stats := pool.Stats()
+		key := [2]string{stats.Name, stats.Target}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		lvs := key[:]
+
+		ch <- prometheus.MustNewConstMetric(connsDesc, prometheus.GaugeValue, float64(stats.ConnCount), lvs...)
+		ch <- prometheus.MustNewConstMetric(idleConnsDesc, prometheus.GaugeValue, float64(stats.ConnIdleCount), lvs...)
+		ch <- prometheus.MustNewConstMetric(closingConnsDesc, prometheus.GaugeValue, float64(stats.ConnClosingCount), lvs...)
+		ch <- prometheus.MustNewConstMetric(refsDesc, prometheus.GaugeValue, float64(stats.RefCount), lvs...)
+		ch <- prometheus.MustNewConstMetric(acquiredDesc, prometheus.CounterValue, float64(stats.Wait.Acquired), lvs...)
+		ch <- prometheus.MustNewConstMetric(timeoutsDesc, prometheus.CounterValue, float64(stats.Wait.Timeouts), lvs...)
+		ch <- prometheus.MustNewConstMetric(dialsDesc, prometheus.CounterValue, float64(stats.Dials), lvs...)
+		ch <- prometheus.MustNewConstMetric(dialFailuresDesc, prometheus.CounterValue, float64(stats.DialFailures), lvs...)
+		ch <- waitHistogram(stats.Wait, lvs)
+	}
+	c.pools = pools
+}
+
+// 将等待时间分布转换为直方图，最后一个无上界的区间即为总数
+func waitHistogram(wait gogrpcpool.WaitStats, lvs []string) prometheus.Metric {
+	count := uint64(0)
+	buckets := map[float64]uint64{}
+	for _, b := range wait.Buckets {
+		if b.UpperBound <= 0 {
+			count = uint64(b.Count)
+			continue
+		}
+		buckets[b.UpperBound.Seconds()] = uint64(b.Count)
+	}
+	return prometheus.MustNewConstHistogram(waitDesc, count, wait.Total.Seconds(), buckets, lvs...)
+}
@@ -0,0 +1,89 @@
+package prom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"github.com/biandoucheng/go-grpc-pool/internal/pooltest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 启动连接池，测试结束时关闭
+func runPool(t *testing.T, name, target string) *gogrpcpool.Pool {
+	t.Helper()
+
+	pool, err := gogrpcpool.New(target,
+		gogrpcpool.WithName(name),
+		gogrpcpool.WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		gogrpcpool.WithMaxConns(1),
+		gogrpcpool.WithMaxIdleConns(1),
+		gogrpcpool.WithMaxRefs(2),
+		gogrpcpool.WithLogger(pooltest.NopLogger{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Run()
+	t.Cleanup(func() { pool.Close(context.Background()) })
+	return pool
+}
+
+func TestCollect(t *testing.T) {
+	target := pooltest.Serve(t)
+	greeter := runPool(t, "greeter", target)
+	dup := runPool(t, "greeter", target)
+	other := runPool(t, "other", target)
+	closed := runPool(t, "closed", target)
+
+	conn, err := greeter.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer greeter.Release(conn)
+	for _, pool := range []*gogrpcpool.Pool{dup, dup, other} {
+		conn, err := pool.Acquire(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pool.Release(conn)
+	}
+	if err := closed.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCollector(greeter, dup, other, closed)
+
+	// 标签相同的连接池只导出先添加的一个，已关闭的连接池不导出
+	expected := `
+# HELP grpcpool_acquisitions_total Total number of successful acquisitions.
+# TYPE grpcpool_acquisitions_total counter
+grpcpool_acquisitions_total{pool="greeter",target="` + target + `"} 1
+grpcpool_acquisitions_total{pool="other",target="` + target + `"} 1
+# HELP grpcpool_refs Number of outstanding connection references.
+# TYPE grpcpool_refs gauge
+grpcpool_refs{pool="greeter",target="` + target + `"} 1
+grpcpool_refs{pool="other",target="` + target + `"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "grpcpool_acquisitions_total", "grpcpool_refs"); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(c)
+	if _, err := reg.Gather(); err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	c.Lock()
+	n := len(c.pools)
+	c.Unlock()
+	if n != 3 {
+		t.Fatalf("collector holds %d pools, want the closed pool removed", n)
+	}
+}
@@ -0,0 +1,27 @@
+module github.com/biandoucheng/go-grpc-pool/metrics/prom
+
+go 1.20
+
+require github.com/biandoucheng/go-grpc-pool v0.0.0-00010101000000-000000000000
+
+replace github.com/biandoucheng/go-grpc-pool => ../..
+
+require (
+	github.com/prometheus/client_golang v1.19.1
+	google.golang.org/grpc v1.62.0
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/protobuf v1.5.4 // indirect
+	github.com/prometheus/client_model v0.5.0 // indirect
+	github.com/prometheus/common v0.48.0 // indirect
+	github.com/prometheus/procfs v0.12.0 // indirect
+	golang.org/x/net v0.21.0 // indirect
+	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240123012728-ef4313101c80 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
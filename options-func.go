@@ -0,0 +1,203 @@
+package gogrpcpool
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// 默认配置
+const (
+	DefaultDescribeDuration = time.Second
+	DefaultCheckPeriod      = time.Second * 3
+	DefaultCloseWait        = time.Second * 20
+	DefaultConnTimeOut      = time.Second * 3
+	DefaultMaxConns         = int32(10)
+	DefaultMaxIdleConns     = int32(2)
+	DefaultMaxRefs          = int32(100)
+	DefaultNewConnRate      = int32(2)
+)
+
+// 函数式配置项，用于 New
+type Option func(*Options)
+
+// 默认配置，New 在此基础上应用各个配置项
+func DefaultOptions(target string) Options {
+	return Options{
+		DescribeDuration: DefaultDescribeDuration,
+		CheckPeriod:      DefaultCheckPeriod,
+		CloseWait:        DefaultCloseWait,
+		ConnTimeOut:      DefaultConnTimeOut,
+		Target:           target,
+		Dopts:            []grpc.DialOption{},
+		MaxConns:         DefaultMaxConns,
+		MaxIdleConns:     DefaultMaxIdleConns,
+		MaxRefs:          DefaultMaxRefs,
+		NewConnRate:      DefaultNewConnRate,
+	}
+}
+
+// 以函数式配置项实例化连接池
+// 1. 从 DefaultOptions(target) 开始，按顺序应用 opts，后面的配置项覆盖前面的
+// 2. 配置了端点发现时 target 可以为空
+func New(target string, opts ...Option) (*Pool, error) {
+	o := DefaultOptions(target)
+	o.Apply(opts...)
+	return NewPool(o)
+}
+
+// 在现有配置上应用配置项
+func (o *Options) Apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// 以结构体整体替换配置，之后的配置项在此基础上继续修改
+func WithOptions(opts Options) Option {
+	return func(o *Options) {
+		*o = opts
+		o.Dopts = append([]grpc.DialOption{}, opts.Dopts...)
+	}
+}
+
+// 连接池名称
+func WithName(name string) Option {
+	return func(o *Options) {
+		o.Name = name
+	}
+}
+
+// 发布到 expvar 的名称
+func WithExpvarName(name string) Option {
+	return func(o *Options) {
+		o.ExpvarName = name
+	}
+}
+
+// 开启调试模式，并设置连接使用情况的打印周期
+func WithDebug(describe time.Duration) Option {
+	return func(o *Options) {
+		o.Debug = true
+		o.DescribeDuration = describe
+	}
+}
+
+// 清理多出连接的周期
+func WithCheckPeriod(d time.Duration) Option {
+	return func(o *Options) {
+		o.CheckPeriod = d
+	}
+}
+
+// 关闭等待周期
+func WithCloseWait(d time.Duration) Option {
+	return func(o *Options) {
+		o.CloseWait = d
+	}
+}
+
+// 新建连接的超时时间
+func WithConnTimeOut(d time.Duration) Option {
+	return func(o *Options) {
+		o.ConnTimeOut = d
+	}
+}
+
+// 初始化连接时使用阻塞模式
+func WithConnBlock(block bool) Option {
+	return func(o *Options) {
+		o.ConnBlock = block
+	}
+}
+
+// 连接进入 Idle 时主动重连
+func WithReconnectIdle(reconnect bool) Option {
+	return func(o *Options) {
+		o.ReconnectIdle = reconnect
+	}
+}
+
+// 追加 grpc 拨号选项
+func WithDialOptions(dopts ...grpc.DialOption) Option {
+	return func(o *Options) {
+		o.Dopts = append(o.Dopts, dopts...)
+	}
+}
+
+// 最大连接数
+func WithMaxConns(n int32) Option {
+	return func(o *Options) {
+		o.MaxConns = n
+	}
+}
+
+// 最少连接数
+func WithMinConns(n int32) Option {
+	return func(o *Options) {
+		o.MinConns = n
+	}
+}
+
+// 最大空闲连接数
+func WithMaxIdleConns(n int32) Option {
+	return func(o *Options) {
+		o.MaxIdleConns = n
+	}
+}
+
+// 每个连接的最大引用数
+func WithMaxRefs(n int32) Option {
+	return func(o *Options) {
+		o.MaxRefs = n
+	}
+}
+
+// 新建连接的引用占比指标
+func WithNewConnRate(n int32) Option {
+	return func(o *Options) {
+		o.NewConnRate = n
+	}
+}
+
+// 端点发现
+func WithDiscovery(d Discovery) Option {
+	return func(o *Options) {
+		o.Discovery = d
+	}
+}
+
+// 调用方所在的位置
+func WithLocality(l Locality) Option {
+	return func(o *Options) {
+		o.Locality = l
+	}
+}
+
+// 指标记录器
+func WithRecorder(r Recorder) Option {
+	return func(o *Options) {
+		o.Recorder = r
+	}
+}
+
+// 链路追踪
+func WithTracer(t Tracer) Option {
+	return func(o *Options) {
+		o.Tracer = t
+	}
+}
+
+// 生命周期事件监听
+func WithEventListener(l EventListener) Option {
+	return func(o *Options) {
+		o.EventListener = l
+	}
+}
+
+// 日志
+func WithLogger(l Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
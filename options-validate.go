@@ -0,0 +1,92 @@
+package gogrpcpool
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidOption = errors.New("invalid option")
+
+// 单个字段的校验错误
+// 1. errors.Is(err, ErrInvalidOption) 对所有字段错误成立
+// 2. Err 为具体的原因，例如 Target 为空时为 ErrTargetNotAvailable
+type OptionError struct {
+	Field string
+	Err   error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("invalid option %s: %v", e.Field, e.Err)
+}
+
+func (e *OptionError) Unwrap() []error {
+	return []error{ErrInvalidOption, e.Err}
+}
+
+// 字段错误
+func optionErrorf(field string, format string, args ...any) error {
+	return &OptionError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+// 校验配置
+// 1. MaxConns、MaxIdleConns、MaxRefs 必填，未设置 Discovery 时 Target 必填，零值视为错误
+// 2. 其余字段的零值表示使用默认值，负值视为错误，默认值见 Options 的字段说明
+// 3. 所有不合法的字段汇总为一个错误返回，可以通过 errors.As 逐个取出 *OptionError
+func (o *Options) Validate() error {
+	errs := []error{}
+
+	if o.Target == "" && o.Discovery == nil {
+		errs = append(errs, &OptionError{Field: "Target", Err: ErrTargetNotAvailable})
+	}
+
+	errs = append(errs, o.limitErrors()...)
+
+	if o.ConnTimeOut < 0 {
+		errs = append(errs, optionErrorf("ConnTimeOut", "must be >= 0, got %v", o.ConnTimeOut))
+	}
+
+	if o.CloseWait < 0 {
+		errs = append(errs, optionErrorf("CloseWait", "must be >= 0, got %v", o.CloseWait))
+	}
+
+	if o.CheckPeriod < 0 {
+		errs = append(errs, optionErrorf("CheckPeriod", "must be >= 0, got %v", o.CheckPeriod))
+	}
+
+	if o.DescribeDuration < 0 {
+		errs = append(errs, optionErrorf("DescribeDuration", "must be >= 0, got %v", o.DescribeDuration))
+	}
+
+	return errors.Join(errs...)
+}
+
+// 校验连接数、引用数相关的限制，Validate 与 Pool.Update 共用
+func (o *Options) limitErrors() []error {
+	errs := []error{}
+
+	if o.MaxConns <= 0 {
+		errs = append(errs, optionErrorf("MaxConns", "must be > 0, got %d", o.MaxConns))
+	}
+
+	if o.MinConns < 0 {
+		errs = append(errs, optionErrorf("MinConns", "must be >= 0, got %d", o.MinConns))
+	} else if o.MaxConns > 0 && o.MinConns > o.MaxConns {
+		errs = append(errs, optionErrorf("MinConns", "must be <= MaxConns (%d), got %d", o.MaxConns, o.MinConns))
+	}
+
+	if o.MaxIdleConns <= 0 {
+		errs = append(errs, optionErrorf("MaxIdleConns", "must be > 0, got %d", o.MaxIdleConns))
+	} else if o.MaxConns > 0 && o.MaxIdleConns > o.MaxConns {
+		errs = append(errs, optionErrorf("MaxIdleConns", "must be <= MaxConns (%d), got %d", o.MaxConns, o.MaxIdleConns))
+	}
+
+	if o.MaxRefs <= 0 {
+		errs = append(errs, optionErrorf("MaxRefs", "must be > 0, got %d", o.MaxRefs))
+	}
+
+	if o.NewConnRate < 0 {
+		errs = append(errs, optionErrorf("NewConnRate", "must be >= 0, got %d", o.NewConnRate))
+	}
+
+	return errs
+}
@@ -0,0 +1,102 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 汇总错误中的所有字段错误
+func optionErrors(t *testing.T, err error) map[string]*OptionError {
+	t.Helper()
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		t.Fatalf("error %v is not an aggregated error", err)
+	}
+
+	fields := map[string]*OptionError{}
+	for _, e := range joined.Unwrap() {
+		oe := &OptionError{}
+		if !errors.As(e, &oe) {
+			t.Fatalf("error %v is not an *OptionError", e)
+		}
+		fields[oe.Field] = oe
+	}
+	return fields
+}
+
+func TestNewPoolOptionErrors(t *testing.T) {
+	cases := []struct {
+		name   string
+		opts   Options
+		fields []string
+	}{
+		{
+			name: "zero and negative",
+			opts: Options{
+				MinConns:         -1,
+				NewConnRate:      -1,
+				ConnTimeOut:      -time.Second,
+				CloseWait:        -time.Second,
+				CheckPeriod:      -time.Second,
+				DescribeDuration: -time.Second,
+			},
+			fields: []string{
+				"Target", "MaxConns", "MinConns", "MaxIdleConns", "MaxRefs", "NewConnRate",
+				"ConnTimeOut", "CloseWait", "CheckPeriod", "DescribeDuration",
+			},
+		},
+		{
+			name:   "limits above MaxConns",
+			opts:   Options{Target: "127.0.0.1:1", MaxConns: 2, MinConns: 3, MaxIdleConns: 3, MaxRefs: -1},
+			fields: []string{"MinConns", "MaxIdleConns", "MaxRefs"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := NewPool(c.opts)
+			if p != nil {
+				t.Fatal("NewPool returned a pool for invalid options")
+			}
+			if !errors.Is(err, ErrInvalidOption) {
+				t.Fatalf("NewPool = %v, want ErrInvalidOption", err)
+			}
+
+			// 每个不合法的字段都有一个错误
+			fields := optionErrors(t, err)
+			for _, field := range c.fields {
+				if fields[field] == nil {
+					t.Errorf("missing error for %s", field)
+				}
+			}
+			if len(fields) != len(c.fields) {
+				t.Fatalf("got %d field errors, want %d: %v", len(fields), len(c.fields), err)
+			}
+		})
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	opts := Options{MaxConns: 1, MaxIdleConns: 1, MaxRefs: 1}
+
+	// 未设置 Discovery 时 Target 必填，具体原因为 ErrTargetNotAvailable
+	err := opts.Validate()
+	if !errors.Is(err, ErrTargetNotAvailable) {
+		t.Fatalf("Validate = %v, want ErrTargetNotAvailable", err)
+	}
+	if oe := optionErrors(t, err)["Target"]; oe == nil || oe.Error() != "invalid option Target: target not available" {
+		t.Fatalf("Target error = %v", oe)
+	}
+
+	// 设置了 Discovery 时 Target 可以为空
+	opts.Discovery = manualDiscovery{}
+	opts.Logger = nopLogger{}
+	p, err := NewPool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close(context.Background())
+}
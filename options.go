@@ -2,53 +2,94 @@ package gogrpcpool
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
 )
 
+// 连接编号序列
+var connSeq uint64
+
 type Options struct {
+	Name       string // 连接池名称，用于指标、调试信息等场景区分不同的连接池
+	ExpvarName string // 设置后连接池的 Stats 会发布到 expvar 的 grpcpool.<ExpvarName> 下，同名的连接池会相互覆盖
+
 	Debug            bool          // 开启调试模式之后，会在运行时打印连接使用情况的统计信息
-	DescribeDuration time.Duration // 连接使用情况的打印周期
-	CheckPeriod      time.Duration // 定时清理多出连接的周期
+	DescribeDuration time.Duration // 连接使用情况的打印周期，默认 1s
+	CheckPeriod      time.Duration // 定时清理多出连接的周期，默认且最小为 3s
+	ReconnectIdle    bool          // 连接进入 Idle 时主动重连，使连接池状态能及时反映端点不可用，开启后 grpc 的空闲超时不再生效，默认关闭
 
-	CloseWait    time.Duration     // 关闭等待周期, 即：当最后一次引用时间距离当前时间超过 closeWait 时，连接可以被关闭
-	ConnTimeOut  time.Duration     // 新建连接的超时时间
+	CloseWait    time.Duration     // 关闭等待周期, 即：当最后一次引用时间距离当前时间超过 closeWait 时，连接可以被关闭，小于 1s 时使用默认值 20s
+	ConnTimeOut  time.Duration     // 新建连接的超时时间，默认 3s
 	ConnBlock    bool              // 初始化连接建立时候是否使用阻塞模式，仅在第一次 初始化空闲连接时候进行阻塞
-	Target       string            // grpc 地址
+	Target       string            // grpc 地址，未设置 Discovery 时必填
 	Dopts        []grpc.DialOption // grpc 拨号选项
-	MaxConns     int32             // 最大连接数, -1 = unlimited
-	MaxIdleConns int32             // 最大空闲连接数, min = 1
-	MaxRefs      int32             // 每个连接的最大可同时引用的次数
-	NewConnRate  int32             // 新连接建立所遵循的指标， 结合 MaxRefs 来确定是否需要建立新连接，当已建立的连接的引用的总次数占它们总的最大可引用次数的 1/NewConnRate 时会尝试建立新的连接;
+	MinConns     int32             // 最少连接数，连接被回收或者拨号失败后在后台补足，避免流量突增时在取连接时拨号，默认 0 不保持，0 ~ MaxConns
+	MaxConns     int32             // 最大连接数，必填，> 0
+	MaxIdleConns int32             // 最大空闲连接数，同时也是初始建立的连接数，必填，1 ~ MaxConns
+	MaxRefs      int32             // 每个连接的最大可同时引用的次数，必填，> 0
+	NewConnRate  int32             // 新连接建立所遵循的指标， 结合 MaxRefs 来确定是否需要建立新连接，当已建立的连接的引用的总次数占它们总的最大可引用次数的 1/NewConnRate 时会尝试建立新的连接，默认且最小为 2;
+
+	Discovery Discovery // 端点发现，设置后连接将建立在发现的端点上，Target 可以为空
+	Locality  Locality  // 调用方所在的位置，设置后优先使用同一位置端点上的连接
+	Recorder  Recorder  // 指标记录器，为空时不记录
+	Tracer    Tracer    // 链路追踪，为空时不追踪
+
+	EventListener EventListener // 生命周期事件监听，为空时忽略所有事件
+	Logger        Logger        // 日志，为空时输出到标准错误，开启 Debug 后输出 debug 级别的日志
 }
 
+// 向 Target 拨号
 func (o *Options) Dial(tunnel chan<- *Conn, block bool) (*Conn, error) {
+	return o.DialEndpoint(Endpoint{Addr: o.Target}, tunnel, block)
+}
+
+// 向指定端点拨号，连接就绪时推入 tunnel
+func (o *Options) DialEndpoint(ep Endpoint, tunnel chan<- *Conn, block bool) (*Conn, error) {
+	conn, err := o.dialConn(ep, block)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.readyTunnel = tunnel
+	go conn.run()
+	return conn, nil
+}
+
+// 拨号并创建连接，由调用方设置就绪通道后启动 run
+func (o *Options) dialConn(ep Endpoint, block bool) (*Conn, error) {
+	if ep.Addr == "" {
+		return nil, ErrTargetNotAvailable
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), o.ConnTimeOut)
 	defer cancel()
 
-	dopts := []grpc.DialOption{}
+	// 注入 stats.Handler 统计连接上的 RPC
+	counter := &connCounter{}
+	dopts := []grpc.DialOption{grpc.WithStatsHandler(&connStatsHandler{counter: counter})}
 	if block {
 		dopts = append(dopts, grpc.WithBlock())
 	}
 	dopts = append(dopts, o.Dopts...)
 
-	grpcconn, err := grpc.DialContext(ctx, o.Target, dopts...)
+	grpcconn, err := grpc.DialContext(ctx, ep.Addr, dopts...)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
 	conn := &Conn{
-		conn:        grpcconn,
-		ref:         0,
-		refMax:      o.MaxRefs,
-		lastReferAt: time.Now(),
-		closeWait:   o.CloseWait,
-		closing:     false,
-		readying:    false,
-		readyTunnel: tunnel,
+		id:        atomic.AddUint64(&connSeq, 1),
+		createdAt: now,
+		conn:      grpcconn,
+		counter:   counter,
+		endpoint:  ep,
+		ref:       0,
+		refMax:    o.MaxRefs,
+		closeWait: o.CloseWait,
 	}
-	go conn.run()
-
+	conn.lastReferAt.Store(now.UnixNano())
 	return conn, nil
 }
@@ -5,8 +5,28 @@ import (
 	"time"
 )
 
-// 寻求一个可用的连接
+// 寻求一个可用的连接，最多等待 d
 func (p *Pool) Acquire(d time.Duration) (*Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return p.AcquireContext(ctx)
+}
+
+// 寻求一个可用的连接，直到 ctx 结束
+func (p *Pool) AcquireContext(ctx context.Context) (conn *Conn, err error) {
+	st := time.Now()
+	ctx, end := p.traceAcquire(ctx)
+	defer func() {
+		end(conn, err)
+		p.observeWait(conn, time.Since(st), err)
+	}()
+
+	// 已关闭的连接池直接拒绝
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
 	// 先把引用次数加一 避免并发导致无法在此新建连接
 	ref := p.addConnRefCount()
 
@@ -14,14 +34,14 @@ func (p *Pool) Acquire(d time.Duration) (*Conn, error) {
 	// 1. 当前连接的引用总数 达到了目标引用占比以上，此时新建一个连接
 	// 2. 通过原子操作申请连接配额，来避免并发新建连接导致连接数超出最大限制
 	if p.connRefReached(ref) && p.askConnQuota() {
-		if _, err := p.newConn(false); err != nil {
+		if _, err := p.newConn(ctx, false); err != nil {
 			// 连接建立失败 连接额度归还
 			p.rbkConnQuota()
 		}
 	}
 
 	// 选取已建立的连接
-	conn, err := p.picker(d)
+	conn, err = p.picker(ctx)
 	if err != nil {
 		p.subConnRefCount()
 	}
@@ -33,24 +53,144 @@ func (p *Pool) Acquire(d time.Duration) (*Conn, error) {
 // 2. 当连接的引用数为0时，说明连接处于空闲状态，对空闲连接数加一
 func (p *Pool) Release(conn *Conn) {
 	conn.release()
+	p.opts.EventListener.OnRelease(conn)
 	if p.subConnRefCount() == 0 {
 		p.addIdleConnCount()
 	}
 }
 
+// 将连接归还到它所属的连接池，用于包装了多个连接池的场景
+func releaseToPool(conn *Conn) error {
+	if conn == nil || conn.pool == nil {
+		return ErrConnNotPooled
+	}
+	conn.pool.Release(conn)
+	return nil
+}
+
 // 从就绪的连接中选一个使用
-func (p *Pool) picker(d time.Duration) (*Conn, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), d)
-	defer cancel()
+func (p *Pool) picker(ctx context.Context) (*Conn, error) {
+	for {
+		var conn *Conn
+
+		// 优先取用本地连接，本地没有就绪的连接时再同时等待所有就绪通道
+		// 就绪队列被替换时，切换到新的就绪队列上等待
+		q := p.readyQueue()
+		select {
+		case conn = <-q.local:
+		default:
+			select {
+			case conn = <-q.local:
+			case conn = <-q.ready:
+			case <-q.resized:
+				continue
+			case <-p.done:
+				return nil, ErrPoolClosed
+			case <-ctx.Done():
+				return nil, ErrWaitConnReadyTimeout
+			}
+		}
+
+		// 就绪后被排空或者变得不健康的连接不再使用，不健康的连接恢复后由 run 重新推入
+		if conn.closing.Load() || !conn.healthy() {
+			conn.unsetReady()
+			continue
+		}
+
+		// 就绪后被直接引用至满载的连接，等待其再次就绪
+		ref, ok := conn.acquire()
+		if !ok {
+			continue
+		}
 
-	select {
-	case conn := <-p.readyTunnel:
 		// 引用数为，说明这个连接刚从空闲状态启用，意味着空闲连接数少了一个
-		if conn.acquire() == 1 {
+		if ref == 1 {
 			p.subIdleConnCount()
 		}
 		return conn, nil
-	case <-ctx.Done():
-		return nil, ErrWaitConnReadyTimeout
+	}
+}
+
+// 不经过就绪通道，直接引用连接
+// 1. pick 返回可用的连接，没有可用连接时返回 nil，返回错误时直接失败
+// 2. 没有可用连接时，按照连接配额新建连接，否则等待直到 ctx 结束
+func (p *Pool) acquireDirect(ctx context.Context, pick func() (*Conn, error)) (conn *Conn, err error) {
+	st := time.Now()
+	ctx, end := p.traceAcquire(ctx)
+	defer func() {
+		end(conn, err)
+		p.observeWait(conn, time.Since(st), err)
+	}()
+
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	ref := p.addConnRefCount()
+
+	if p.connRefReached(ref) && p.askConnQuota() {
+		if _, err := p.newConn(ctx, false); err != nil {
+			p.rbkConnQuota()
+		}
+	}
+
+	for {
+		conn, err = pick()
+		if err != nil {
+			p.subConnRefCount()
+			return nil, err
+		}
+		if conn != nil {
+			return conn, nil
+		}
+
+		// 没有可用的连接，尝试新建连接
+		if p.askConnQuota() {
+			if _, err := p.newConn(ctx, false); err == nil {
+				continue
+			}
+			p.rbkConnQuota()
+		}
+
+		select {
+		case <-p.done:
+			p.subConnRefCount()
+			return nil, ErrPoolClosed
+		case <-ctx.Done():
+			p.subConnRefCount()
+			return nil, ErrWaitConnReadyTimeout
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// 引用满足 match 的连接中引用数最少的可用连接，没有可用连接时返回 nil
+// 1. 直接引用连接，连接的就绪状态保持不变
+func (p *Pool) referConn(match func(*Conn) bool) *Conn {
+	p.RLock()
+	defer p.RUnlock()
+
+	for {
+		var best *Conn
+		for _, conn := range p.conns {
+			if conn.closing.Load() || conn.isMaxRef() || !conn.healthy() || !match(conn) {
+				continue
+			}
+			if best == nil || conn.chkRef() < best.chkRef() {
+				best = conn
+			}
+		}
+
+		if best == nil {
+			return nil
+		}
+
+		// 并发引用导致连接已满时重新选择
+		if ref, ok := best.refer(); ok {
+			if ref == 1 {
+				p.subIdleConnCount()
+			}
+			return best
+		}
 	}
 }
@@ -7,7 +7,7 @@ import "sync/atomic"
 // 连接数加一
 func (p *Pool) addConnCount() {
 	count := atomic.AddInt32(&p.connCount, 1)
-	if count > p.opts.MaxConns {
+	if count > p.maxConns() {
 		atomic.AddInt32(&p.connCount, -1)
 	}
 }
@@ -25,7 +25,7 @@ func (p *Pool) chkConnCount() int32 {
 // 空闲连接数加一
 func (p *Pool) addIdleConnCount() {
 	count := atomic.AddInt32(&p.connIdleCount, 1)
-	if count > p.opts.MaxConns {
+	if count > p.maxConns() {
 		atomic.AddInt32(&p.connIdleCount, -1)
 	}
 }
@@ -0,0 +1,58 @@
+package gogrpcpool
+
+import "context"
+
+// 按 key 寻求一个连接，相同的 key 总是优先落在同一个端点上
+// 1. 通过一致性哈希环选取首选端点，端点变化时只有少量 key 会被重新映射
+// 2. 首选端点上还没有连接时，按照连接配额先在首选端点上新建连接
+// 3. 首选端点上的连接都达到最大引用数时，沿哈希环顺延到下一个端点
+// 4. 所有端点都满载时，按照连接配额新建连接，否则等待直到 ctx 结束
+// 5. 使用完毕后同样需要调用 Release 释放
+func (p *Pool) AcquireByKey(ctx context.Context, key string) (*Conn, error) {
+	return p.acquireDirect(ctx, func() (*Conn, error) {
+		p.RLock()
+		addrs := p.ring.lookup(key)
+		ep, dial := p.unconnectedEndpoint(addrs)
+		p.RUnlock()
+
+		if len(addrs) == 0 {
+			return nil, ErrTargetNotAvailable
+		}
+
+		if dial && p.askConnQuota() {
+			if _, err := p.newEndpointConn(ctx, ep, false); err != nil {
+				p.rbkConnQuota()
+			}
+		}
+
+		for _, addr := range addrs {
+			if conn := p.referConn(func(c *Conn) bool { return c.endpoint.Addr == addr }); conn != nil {
+				return conn, nil
+			}
+		}
+		return nil, nil
+	})
+}
+
+// 首选端点上是否还没有未关闭的连接，需在持有锁的情况下调用
+func (p *Pool) unconnectedEndpoint(addrs []string) (Endpoint, bool) {
+	if len(addrs) == 0 {
+		return Endpoint{}, false
+	}
+
+	for _, conn := range p.conns {
+		if conn.endpoint.Addr == addrs[0] && !conn.closing.Load() {
+			return Endpoint{}, false
+		}
+	}
+
+	if p.opts.Discovery == nil {
+		return Endpoint{Addr: p.opts.Target}, true
+	}
+	for _, ep := range p.endpoints {
+		if ep.Addr == addrs[0] {
+			return ep, true
+		}
+	}
+	return Endpoint{}, false
+}
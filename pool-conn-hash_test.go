@@ -0,0 +1,180 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAcquireByKeyConcurrent(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 8
+	p := runPool(t, opts)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				conn, err := p.AcquireByKey(ctx, "user-1")
+				cancel()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				p.Release(conn)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if stats := p.Stats(); stats.RefCount != 0 || stats.ConnCount != 1 {
+		t.Fatalf("refs = %d, conns = %d, want 0 and 1", stats.RefCount, stats.ConnCount)
+	}
+}
+
+// 按 key 取连接，返回连接所在的端点
+func acquireAddr(t *testing.T, p *Pool, key string) string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn, err := p.AcquireByKey(ctx, key)
+	if err != nil {
+		t.Fatalf("AcquireByKey(%s): %v", key, err)
+	}
+	p.Release(conn)
+	return conn.Endpoint().Addr
+}
+
+func TestAcquireByKeyAffinity(t *testing.T) {
+	opts := testOptions("")
+	opts.Discovery = manualDiscovery{}
+	opts.MaxConns = 6
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 4
+	p := runPool(t, opts)
+	p.updateEndpoints(serveEndpoints(t, 3))
+
+	used := map[string]int{}
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		want := p.ring.lookup(key)[0]
+		if got := acquireAddr(t, p, key); got != want {
+			t.Fatalf("key %s routed to %s, want %s", key, got, want)
+		}
+		used[want] += 1
+	}
+	if len(used) != 3 {
+		t.Fatalf("keys spread over %d endpoints, want 3", len(used))
+	}
+}
+
+func TestAcquireByKeyBoundedLoad(t *testing.T) {
+	opts := testOptions("")
+	opts.Discovery = manualDiscovery{}
+	opts.MaxConns = 2
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 1
+	p := runPool(t, opts)
+	p.updateEndpoints(serveEndpoints(t, 2))
+
+	addrs := p.ring.lookup("user-1")
+
+	first, err := p.AcquireByKey(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Endpoint().Addr != addrs[0] {
+		t.Fatalf("first conn on %s, want %s", first.Endpoint().Addr, addrs[0])
+	}
+
+	// 首选端点满载时顺延到哈希环上的下一个端点
+	second, err := p.AcquireByKey(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Endpoint().Addr != addrs[1] {
+		t.Fatalf("second conn on %s, want fallback %s", second.Endpoint().Addr, addrs[1])
+	}
+
+	// 所有端点都满载且没有连接配额时等待
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := p.AcquireByKey(ctx, "user-1"); !errors.Is(err, ErrWaitConnReadyTimeout) {
+		t.Fatalf("AcquireByKey = %v, want ErrWaitConnReadyTimeout", err)
+	}
+
+	// 首选端点空闲后重新落回首选端点
+	p.Release(first)
+	p.Release(second)
+	if got := acquireAddr(t, p, "user-1"); got != addrs[0] {
+		t.Fatalf("key routed to %s after release, want %s", got, addrs[0])
+	}
+}
+
+func TestAcquireByKeyRemap(t *testing.T) {
+	opts := testOptions("")
+	opts.Discovery = manualDiscovery{}
+	opts.MaxConns = 8
+	opts.MaxIdleConns = 1
+	opts.MaxRefs = 4
+	p := runPool(t, opts)
+
+	eps := serveEndpoints(t, 5)
+	p.updateEndpoints(eps[:4])
+
+	keys := []string{}
+	before := map[string]string{}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("user-%d", i)
+		keys = append(keys, key)
+		before[key] = acquireAddr(t, p, key)
+	}
+
+	// 移除一个端点，只有原本落在该端点上的 key 被重新映射
+	removed := eps[3].Addr
+	p.updateEndpoints(eps[:3])
+	moved := 0
+	for _, key := range keys {
+		got := acquireAddr(t, p, key)
+		if got == removed {
+			t.Fatalf("key %s still routed to removed endpoint", key)
+		}
+		if before[key] != removed && got != before[key] {
+			t.Fatalf("key %s moved from %s to %s", key, before[key], got)
+		}
+		if got != before[key] {
+			moved += 1
+		}
+		before[key] = got
+	}
+	if moved == 0 {
+		t.Fatal("no key was remapped from the removed endpoint")
+	}
+
+	// 新增一个端点，key 只会被重新映射到新端点上
+	added := eps[4].Addr
+	p.updateEndpoints(append(eps[:3:3], eps[4]))
+	moved = 0
+	for _, key := range keys {
+		got := acquireAddr(t, p, key)
+		if got != before[key] && got != added {
+			t.Fatalf("key %s moved from %s to %s, want only moves to %s", key, before[key], got, added)
+		}
+		if got == added {
+			moved += 1
+		}
+	}
+	if moved == 0 || moved > len(keys)/2 {
+		t.Fatalf("%d of %d keys moved to the new endpoint", moved, len(keys))
+	}
+}
@@ -2,12 +2,18 @@ package gogrpcpool
 
 import "time"
 
-// 空闲连接数管理
+// 空闲连接数管理，连接池关闭时退出
 func (p *Pool) idleConnManager() {
 	tricker := time.NewTicker(p.opts.CheckPeriod)
+	defer tricker.Stop()
+
 	for {
-		<-tricker.C
-		p.reset()
+		select {
+		case <-p.done:
+			return
+		case <-tricker.C:
+			p.reset()
+		}
 	}
 }
 
@@ -19,24 +25,28 @@ func (p *Pool) reset() {
 	idleCount := int32(0)
 	connCount := int32(0)
 	closeCount := int32(0)
+	healthyCount := int32(0)
 	conns := []*Conn{}
 
 	for _, conn := range p.conns {
 		// 移除需要关闭的连接
 		if conn.removeAble() {
-			conn.close()
+			p.closeConn(conn)
 			p.rbkConnQuota()
 			continue
 		}
 
 		// 统计仍处于待关闭状态的
-		if conn.closing {
+		if conn.closing.Load() {
 			closeCount += 1
 		} else {
 			// 统计空闲连接数
 			if conn.chkRef() == 0 {
 				idleCount += 1
 			}
+			if conn.healthy() {
+				healthyCount += 1
+			}
 		}
 
 		// 剩余的连接
@@ -44,27 +54,41 @@ func (p *Pool) reset() {
 		conns = append(conns, conn)
 	}
 
-	// 标记下次需要关闭的连接
-	if shouldClosed := idleCount - p.opts.MaxIdleConns; shouldClosed > 0 {
+	// 标记下次需要关闭的连接，不会使未关闭的连接数低于 MinConns
+	shouldClosed := idleCount - p.maxIdleConns()
+	if keep := connCount - closeCount - p.minConns(); shouldClosed > keep {
+		shouldClosed = keep
+	}
+	if shouldClosed > 0 {
 		for i, conn := range conns {
 			if shouldClosed <= 0 {
 				break
 			}
 
-			if conn.closing {
+			if conn.closing.Load() {
 				continue
 			}
 
 			// 这里尝试设置连接状态为关闭中
 			if conns[i].setClosing(false) {
+				p.opts.EventListener.OnConnClosing(conns[i])
 				closeCount += 1
 				shouldClosed -= 1
+				if conns[i].healthy() {
+					healthyCount -= 1
+				}
 			}
 		}
 	}
 
 	// 重置连接
 	p.conns = conns
+	p.refreshState()
+
+	// 连接被回收或者失败后补足 MinConns
+	if healthyCount < p.minConns() {
+		p.warm()
+	}
 
 	// 重置统计值
 	p.resetConnCount(connCount)
@@ -17,7 +17,7 @@ func (p *Pool) askConnQuota() bool {
 // 归还一个连接额度
 func (p *Pool) rbkConnQuota() {
 	quota := atomic.AddInt32(&p.connQuota, 1)
-	if quota > p.opts.MaxConns {
+	if quota > p.maxConns() {
 		atomic.AddInt32(&p.connQuota, -1)
 	}
 }
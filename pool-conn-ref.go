@@ -27,7 +27,7 @@ func (p *Pool) chkConnReferd() int32 {
 
 // 当前连接的引用总数是否达到了目标比率以上
 func (p *Pool) connRefReached(ref int32) bool {
-	rate := p.opts.MaxRefs * (p.chkConnCount() - p.chkClosingConnCount()) / p.opts.NewConnRate
+	rate := p.maxRefs() * (p.chkConnCount() - p.chkClosingConnCount()) / atomic.LoadInt32(&p.opts.NewConnRate)
 	return ref >= rate
 }
 
@@ -0,0 +1,67 @@
+package gogrpcpool
+
+import (
+	"context"
+	"time"
+)
+
+// 通知后台补足 MinConns，不会阻塞
+func (p *Pool) warm() {
+	select {
+	case p.warmCh <- struct{}{}:
+	default:
+	}
+}
+
+// 最少连接数管理
+// 1. 收到通知或者每隔 CheckPeriod 检查一次，健康的连接数低于 MinConns 时在后台拨号补足
+// 2. 拨号失败时等待下一次检查再重试
+// 3. 连接池关闭时退出
+func (p *Pool) minConnsManager() {
+	tricker := time.NewTicker(p.opts.CheckPeriod)
+	defer tricker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-p.warmCh:
+		case <-tricker.C:
+		}
+		p.prewarm()
+	}
+}
+
+// 按照 MinConns 补足连接，连接配额不足时停止
+func (p *Pool) prewarm() {
+	dialed := 0
+	for p.healthyConnCount() < p.minConns() {
+		if p.isClosed() || !p.askConnQuota() {
+			break
+		}
+
+		if _, err := p.newConn(context.Background(), false); err != nil {
+			p.rbkConnQuota()
+			break
+		}
+		dialed += 1
+	}
+
+	if dialed > 0 {
+		p.logger.Debug("prewarm conns", "dialed", dialed, "minConns", p.minConns())
+	}
+}
+
+// 未处于关闭中且健康的连接数，连接失败的连接不计入 MinConns
+func (p *Pool) healthyConnCount() int32 {
+	p.RLock()
+	defer p.RUnlock()
+
+	count := int32(0)
+	for _, conn := range p.conns {
+		if !conn.closing.Load() && conn.healthy() {
+			count += 1
+		}
+	}
+	return count
+}
@@ -0,0 +1,68 @@
+package gogrpcpool
+
+import (
+	"testing"
+	"time"
+)
+
+// 等待 cond 成立，最多等待 2s
+func eventually(t *testing.T, msg string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal(msg)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMinConnsAfterReset(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MinConns = 3
+	opts.MaxIdleConns = 1
+	p := runPool(t, opts)
+
+	eventually(t, "MinConns not reached after Run", func() bool { return p.healthyConnCount() == 3 })
+
+	// 连接被回收后在后台补足
+	p.Lock()
+	reaped := map[uint64]bool{}
+	for _, conn := range p.conns {
+		conn.drain()
+		reaped[conn.id] = true
+	}
+	p.Unlock()
+	p.reset()
+
+	eventually(t, "MinConns not restored after reset", func() bool { return p.healthyConnCount() == 3 })
+	p.RLock()
+	defer p.RUnlock()
+	for _, conn := range p.conns {
+		if reaped[conn.id] {
+			t.Fatalf("reaped conn %d is still in the pool", conn.id)
+		}
+	}
+}
+
+func TestMinConnsReplacesFailed(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MinConns = 2
+	opts.MaxIdleConns = 1
+	p := runPool(t, opts)
+
+	eventually(t, "MinConns not reached after Run", func() bool { return p.healthyConnCount() == 2 })
+
+	// 连接失败后不再计入 MinConns，在后台补足
+	p.RLock()
+	failed := p.conns[0]
+	p.RUnlock()
+	failed.conn.Close()
+	p.reset()
+
+	eventually(t, "failed conn was not replaced", func() bool { return p.healthyConnCount() == 2 })
+	if stats := p.Stats(); stats.ConnCount != 3 {
+		t.Fatalf("conns = %d, want 2 healthy and 1 failed", stats.ConnCount)
+	}
+}
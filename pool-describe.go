@@ -2,35 +2,51 @@ package gogrpcpool
 
 import (
 	"fmt"
-	"log"
 	"strings"
-	"sync/atomic"
 	"time"
 )
 
 // debug 打印
 func (p *Pool) DescribeTimer() {
 	tricker := time.NewTicker(p.opts.DescribeDuration)
+	defer tricker.Stop()
+
 	for {
-		<-tricker.C
-		log.Println(p.Describe())
+		select {
+		case <-p.done:
+			return
+		case <-tricker.C:
+			p.logger.Debug("describe pool", "stats", "\n"+p.Describe())
+		}
 	}
 }
 
-// 输出连接池状态
+// 输出连接池状态，基于 Stats 快照生成
 func (p *Pool) Describe() string {
-	summary := fmt.Sprintf("Pool{connCount:%d, refCount:%d, connIdleCount:%d, connClosingCount:%d}\nConns:\n",
-		atomic.LoadInt32(&p.connCount),
-		atomic.LoadInt32(&p.refCount),
-		atomic.LoadInt32(&p.connIdleCount),
-		atomic.LoadInt32(&p.connClosingCount))
+	stats := p.Stats()
+	summary := fmt.Sprintf("Pool{state:%s, connCount:%d, refCount:%d, connIdleCount:%d, connClosingCount:%d, acquired:%d, timeouts:%d, dials:%d, dialFailures:%d}\nConns:\n",
+		stats.State,
+		stats.ConnCount,
+		stats.RefCount,
+		stats.ConnIdleCount,
+		stats.ConnClosingCount,
+		stats.Wait.Acquired,
+		stats.Wait.Timeouts,
+		stats.Dials,
+		stats.DialFailures)
 
 	conns := []string{}
-	p.RLock()
-	defer p.RUnlock()
-	for _, conn := range p.conns {
-		conns = append(conns, conn.Describe())
+	for _, cs := range stats.Conns {
+		conns = append(conns, cs.describe())
 	}
+	summary += strings.Join(conns, "\n")
 
-	return summary + strings.Join(conns, "\n")
+	if !p.opts.Locality.isZero() {
+		localities := []string{}
+		for _, ls := range stats.Localities {
+			localities = append(localities, ls.describe())
+		}
+		summary += "\nLocalities:\n" + strings.Join(localities, "\n")
+	}
+	return summary
 }
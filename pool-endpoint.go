@@ -0,0 +1,132 @@
+package gogrpcpool
+
+import "context"
+
+// 端点管理
+
+// 监听端点变化
+func (p *Pool) watchEndpoints(ctx context.Context) {
+	if err := p.opts.Discovery.Watch(ctx, p.updateEndpoints); err != nil && ctx.Err() == nil {
+		p.logger.Error("watch endpoints failed", "error", err)
+	}
+}
+
+// 更新连接池的端点集合，仅作为端点发现的回调，未配置端点发现的连接池只使用 Target
+// 1. 已被移除的端点上的连接会被标记为关闭中，待引用数归零后由 idleConnManager 关闭
+// 2. 新增的端点会尝试各建立一个连接，使其能够尽快承接流量
+// 3. 当没有存活的连接时，除了新增端点上的连接，再按照最大空闲数补足连接
+// 4. 更新后在后台补足 MinConns
+func (p *Pool) updateEndpoints(endpoints []Endpoint) {
+	defer p.warm()
+
+	p.Lock()
+	known := map[string]bool{}
+	for _, ep := range p.endpoints {
+		known[ep.Addr] = true
+	}
+
+	current := map[string]bool{}
+	eps := []Endpoint{}
+	added := []Endpoint{}
+	for _, ep := range endpoints {
+		if ep.Addr == "" || current[ep.Addr] {
+			continue
+		}
+		current[ep.Addr] = true
+		eps = append(eps, ep)
+		if !known[ep.Addr] {
+			added = append(added, ep)
+		}
+	}
+
+	alive := 0
+	for _, conn := range p.conns {
+		if !current[conn.endpoint.Addr] {
+			if !conn.closing.Load() {
+				conn.drain()
+				p.opts.EventListener.OnConnClosing(conn)
+			}
+			continue
+		}
+		if !conn.closing.Load() {
+			alive += 1
+		}
+	}
+	p.endpoints = eps
+	p.ring = newHashRing(eps)
+	p.Unlock()
+
+	dialed := int32(0)
+	for _, ep := range added {
+		if !p.askConnQuota() {
+			break
+		}
+		if _, err := p.newEndpointConn(context.Background(), ep, false); err != nil {
+			p.rbkConnQuota()
+			continue
+		}
+		dialed += 1
+	}
+
+	if alive == 0 {
+		p.initConns(dialed)
+	}
+}
+
+// 查询当前的端点集合
+func (p *Pool) Endpoints() []Endpoint {
+	p.RLock()
+	defer p.RUnlock()
+
+	eps := make([]Endpoint, len(p.endpoints))
+	copy(eps, p.endpoints)
+	return eps
+}
+
+// 选取一个用于新建连接的端点
+// 1. 未配置端点发现时，使用 Options.Target
+// 2. 配置了位置时优先选取本地端点
+// 3. 优先选取 连接数/权重 最小的端点，需在持有锁的情况下调用
+func (p *Pool) pickEndpoint() (Endpoint, error) {
+	if p.opts.Discovery == nil {
+		return Endpoint{Addr: p.opts.Target}, nil
+	}
+
+	if len(p.endpoints) == 0 {
+		return Endpoint{}, ErrTargetNotAvailable
+	}
+
+	counts := map[string]int32{}
+	healthy := map[string]int32{}
+	for _, conn := range p.conns {
+		if !conn.closing.Load() {
+			counts[conn.endpoint.Addr] += 1
+			if conn.healthy() {
+				healthy[conn.endpoint.Addr] += 1
+			}
+		}
+	}
+
+	// 配置了位置时优先在本地端点上建立连接，已有连接且全都不健康的端点不参与选择
+	candidates := p.endpoints
+	if !p.opts.Locality.isZero() {
+		local := []Endpoint{}
+		for _, ep := range p.endpoints {
+			if p.opts.Locality.match(ep) && (counts[ep.Addr] == 0 || healthy[ep.Addr] > 0) {
+				local = append(local, ep)
+			}
+		}
+		if len(local) > 0 {
+			candidates = local
+		}
+	}
+
+	best := candidates[0]
+	for _, ep := range candidates[1:] {
+		// counts[ep]/weight(ep) < counts[best]/weight(best)
+		if counts[ep.Addr]*best.weight() < counts[best.Addr]*ep.weight() {
+			best = ep
+		}
+	}
+	return best, nil
+}
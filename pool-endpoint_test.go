@@ -0,0 +1,56 @@
+package gogrpcpool
+
+import "testing"
+
+// 每个端点上未关闭的连接数
+func endpointConns(p *Pool) map[string]int {
+	p.RLock()
+	defer p.RUnlock()
+
+	counts := map[string]int{}
+	for _, conn := range p.conns {
+		if !conn.closing.Load() {
+			counts[conn.endpoint.Addr] += 1
+		}
+	}
+	return counts
+}
+
+func TestUpdateEndpointsDialsAdded(t *testing.T) {
+	opts := testOptions("")
+	opts.Discovery = manualDiscovery{}
+	opts.MaxConns = 8
+	opts.MaxIdleConns = 1
+	p := runPool(t, opts)
+
+	// 首次更新时每个端点都建立一个连接，不受最大空闲数限制
+	eps := serveEndpoints(t, 4)
+	p.updateEndpoints(eps[:3])
+	counts := endpointConns(p)
+	for _, ep := range eps[:3] {
+		if counts[ep.Addr] != 1 {
+			t.Fatalf("conns per endpoint = %v, want 1 on each of %d endpoints", counts, 3)
+		}
+	}
+
+	// 新增的端点建立一个连接，被移除的端点上的连接被排空
+	p.updateEndpoints(eps[1:])
+	counts = endpointConns(p)
+	if len(counts) != 3 || counts[eps[0].Addr] != 0 || counts[eps[3].Addr] != 1 {
+		t.Fatalf("conns per endpoint = %v after update", counts)
+	}
+}
+
+func TestUpdateEndpointsInitConns(t *testing.T) {
+	opts := testOptions("")
+	opts.Discovery = manualDiscovery{}
+	opts.MaxConns = 8
+	opts.MaxIdleConns = 3
+	p := runPool(t, opts)
+
+	// 端点数少于最大空闲数时，按照最大空闲数补足连接
+	p.updateEndpoints(serveEndpoints(t, 1))
+	if stats := p.Stats(); stats.ConnCount != 3 {
+		t.Fatalf("conns = %d, want MaxIdleConns 3", stats.ConnCount)
+	}
+}
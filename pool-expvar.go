@@ -0,0 +1,52 @@
+package gogrpcpool
+
+import (
+	"expvar"
+	"sync"
+)
+
+// 连接池在 expvar 中发布的位置，即 /debug/vars 中的 grpcpool 字段
+const expvarRoot = "grpcpool"
+
+var (
+	expvarPools *expvar.Map
+	expvarOnce  sync.Once
+
+	expvarMu     sync.Mutex
+	expvarOwners = map[string]*Pool{} // 每个名称当前发布的连接池
+)
+
+// 将连接池的 Stats 发布到 expvar 的 grpcpool.<ExpvarName> 下，每次读取时实时生成
+// 1. 同名的连接池后发布的覆盖先发布的
+func (p *Pool) publishExpvar() {
+	if p.opts.ExpvarName == "" {
+		return
+	}
+
+	expvarOnce.Do(func() {
+		expvarPools = expvar.NewMap(expvarRoot)
+	})
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	expvarOwners[p.opts.ExpvarName] = p
+	expvarPools.Set(p.opts.ExpvarName, expvar.Func(func() any {
+		return p.Stats()
+	}))
+}
+
+// 从 expvar 中移除连接池
+// 1. 仅当该名称下发布的仍是这个连接池时才移除，不影响后发布的同名连接池
+func (p *Pool) unpublishExpvar() {
+	if p.opts.ExpvarName == "" {
+		return
+	}
+
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if expvarOwners[p.opts.ExpvarName] != p {
+		return
+	}
+	delete(expvarOwners, p.opts.ExpvarName)
+	expvarPools.Delete(p.opts.ExpvarName)
+}
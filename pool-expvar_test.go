@@ -0,0 +1,58 @@
+package gogrpcpool
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+// expvar 中发布的连接池名称
+func publishedPool(t *testing.T, name string) (string, bool) {
+	t.Helper()
+
+	v := expvar.Get(expvarRoot).(*expvar.Map).Get(name)
+	if v == nil {
+		return "", false
+	}
+
+	stats := Stats{}
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	return stats.Name, true
+}
+
+func TestExpvarSameName(t *testing.T) {
+	newPool := func(name string) *Pool {
+		opts := testOptions("127.0.0.1:1")
+		opts.Name = name
+		opts.ExpvarName = "expvar-test"
+		p, err := NewPool(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	a := newPool("a")
+	if name, ok := publishedPool(t, "expvar-test"); !ok || name != "a" {
+		t.Fatalf("published %q, want a", name)
+	}
+
+	// 同名的连接池后发布的覆盖先发布的，先发布的关闭时不会移除后发布的
+	b := newPool("b")
+	if err := a.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if name, ok := publishedPool(t, "expvar-test"); !ok || name != "b" {
+		t.Fatalf("published %q after closing a, want b", name)
+	}
+
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := publishedPool(t, "expvar-test"); ok {
+		t.Fatal("entry still published after closing b")
+	}
+}
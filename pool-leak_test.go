@@ -0,0 +1,92 @@
+package gogrpcpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+// 固定端点的端点发现
+type staticDiscovery []Endpoint
+
+func (d staticDiscovery) Watch(ctx context.Context, update func([]Endpoint)) error {
+	update(d)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestCloseStopsGoroutines(t *testing.T) {
+	a, b := serve(t), serve(t)
+	ignore := goleak.IgnoreCurrent()
+
+	for i := 0; i < 3; i++ {
+		opts := testOptions("")
+		opts.Debug = true
+		opts.DescribeDuration = time.Millisecond
+		opts.Discovery = staticDiscovery{{Addr: a}, {Addr: b}}
+		opts.MinConns = 3
+
+		p, err := NewPool(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Run()
+
+		if err := p.WaitReady(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		eventually(t, "MinConns not reached", func() bool { return p.healthyConnCount() >= 3 })
+		conn, err := p.Acquire(time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Release(conn)
+
+		if err := p.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goleak.VerifyNone(t, ignore)
+}
+
+func TestFailoverCloseStopsGoroutines(t *testing.T) {
+	a, b := serve(t), serve(t)
+	ignore := goleak.IgnoreCurrent()
+
+	primary, err := NewPool(testOptions(a))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backup, err := NewPool(testOptions(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary.Run()
+	backup.Run()
+
+	fp, err := NewFailoverPool(FailoverOptions{ProbeInterval: time.Millisecond}, primary, backup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp.Run()
+
+	conn, err := fp.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fp.Release(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	fp.Close()
+	for _, p := range []*Pool{primary, backup} {
+		if err := p.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	goleak.VerifyNone(t, ignore)
+}
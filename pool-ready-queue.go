@@ -0,0 +1,58 @@
+package gogrpcpool
+
+// 就绪队列
+// 1. ready 为所有就绪连接的通道，local 为与调用方处于同一位置的就绪连接的通道，容量均为 MaxConns
+// 2. 调整 MaxConns 时会整体替换为新的就绪队列，并关闭旧队列的 resized，等待在旧队列上的收发方通过它切换到新队列
+type readyQueue struct {
+	ready   chan *Conn
+	local   chan *Conn
+	resized chan struct{}
+}
+
+func newReadyQueue(size int32) *readyQueue {
+	return &readyQueue{
+		ready:   make(chan *Conn, size),
+		local:   make(chan *Conn, size),
+		resized: make(chan struct{}),
+	}
+}
+
+// 连接应推入的就绪通道
+func (q *readyQueue) tunnel(local bool) chan *Conn {
+	if local {
+		return q.local
+	}
+	return q.ready
+}
+
+// 当前的就绪队列
+func (p *Pool) readyQueue() *readyQueue {
+	return p.queue.Load()
+}
+
+// 按照新的容量替换就绪队列
+// 1. 旧队列中缓冲的连接尽量移入新队列，新队列放不下的连接移除就绪状态，由连接的 run 重新推入
+// 2. 需在持有锁的情况下调用
+func (p *Pool) resizeReadyQueue(size int32) {
+	old := p.queue.Load()
+	q := newReadyQueue(size)
+	p.queue.Store(q)
+	close(old.resized)
+
+	move := func(from, to chan *Conn) {
+		for {
+			select {
+			case conn := <-from:
+				select {
+				case to <- conn:
+				default:
+					conn.unsetReady()
+				}
+			default:
+				return
+			}
+		}
+	}
+	move(old.local, q.local)
+	move(old.ready, q.ready)
+}
@@ -0,0 +1,154 @@
+package gogrpcpool
+
+import (
+	"context"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// 连接池的生命周期状态
+// 1. New -> Starting：调用 Run
+// 2. Starting/Degraded -> Running：至少有一个连接处于 Ready 状态
+// 3. Starting/Running -> Degraded：存在连接，但没有一个健康的连接，或者启动后所有连接都被移除
+// 未开启 ReconnectIdle 时，断开后处于 Idle 的连接在下一次调用时才重连，仍被视为健康
+// 4. 任意状态 -> Draining：调用 Close，等待连接被归还
+// 5. Draining -> Closed：所有连接关闭，Closed 为终止状态
+type PoolState int32
+
+const (
+	StateNew PoolState = iota
+	StateStarting
+	StateRunning
+	StateDegraded
+	StateDraining
+	StateClosed
+)
+
+func (s PoolState) String() string {
+	switch s {
+	case StateNew:
+		return "new"
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateDegraded:
+		return "degraded"
+	case StateDraining:
+		return "draining"
+	case StateClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// 当前状态
+func (p *Pool) State() PoolState {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	return p.state
+}
+
+// 状态变化通知，返回的通道在下一次状态变化时被关闭
+// 1. 每次状态变化后需要重新获取
+func (p *Pool) StateChanged() <-chan struct{} {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	return p.stateChanged
+}
+
+// 等待连接池进入状态 s，直到 ctx 结束
+// 1. 连接池已关闭且 s 不是 Closed 时返回 ErrPoolClosed
+func (p *Pool) WaitForState(ctx context.Context, s PoolState) error {
+	for {
+		p.stateMu.Lock()
+		state, changed := p.state, p.stateChanged
+		p.stateMu.Unlock()
+
+		if state == s {
+			return nil
+		}
+		if state == StateClosed {
+			return ErrPoolClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// 等待连接池可用，即进入 Running 状态
+func (p *Pool) WaitReady(ctx context.Context) error {
+	return p.WaitForState(ctx, StateRunning)
+}
+
+// 切换状态并通知等待方，Closed 之后不再切换
+func (p *Pool) setState(s PoolState) {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+	p.changeState(s)
+}
+
+// 仅当状态为 from 时切换为 to
+func (p *Pool) compareAndSetState(from, to PoolState) bool {
+	p.stateMu.Lock()
+	defer p.stateMu.Unlock()
+
+	if p.state != from {
+		return false
+	}
+	p.changeState(to)
+	return true
+}
+
+// 切换状态，需在持有 stateMu 的情况下调用
+func (p *Pool) changeState(s PoolState) {
+	from := p.state
+	if from == s || from == StateClosed {
+		return
+	}
+
+	p.state = s
+	close(p.stateChanged)
+	p.stateChanged = make(chan struct{})
+	p.logger.Info("pool state changed", "from", from.String(), "to", s.String())
+}
+
+// 按照连接的健康状况刷新状态，需在持有读锁或写锁的情况下调用
+// 1. 仅在 Starting、Running、Degraded 之间切换
+func (p *Pool) refreshState() {
+	ready, healthy := false, false
+	for _, conn := range p.conns {
+		if conn.closing.Load() || conn.conn == nil {
+			continue
+		}
+		if conn.conn.GetState() == connectivity.Ready {
+			ready = true
+		}
+		if conn.healthy() {
+			healthy = true
+		}
+	}
+
+	switch {
+	case ready:
+		p.compareAndSetState(StateStarting, StateRunning)
+		p.compareAndSetState(StateDegraded, StateRunning)
+	case !healthy:
+		p.compareAndSetState(StateRunning, StateDegraded)
+		if len(p.conns) > 0 {
+			p.compareAndSetState(StateStarting, StateDegraded)
+		}
+	}
+}
+
+// 连接的 connectivity 状态发生变化
+func (p *Pool) onConnStateChange() {
+	p.RLock()
+	defer p.RUnlock()
+	p.refreshState()
+}
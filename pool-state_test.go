@@ -0,0 +1,103 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/connectivity"
+)
+
+// 等待连接池进入状态 s，最多等待 5s
+func waitState(t *testing.T, p *Pool, s PoolState) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.WaitForState(ctx, s); err != nil {
+		t.Fatalf("WaitForState(%s) = %v, state is %s", s, err, p.State())
+	}
+}
+
+func TestStateTransitions(t *testing.T) {
+	addr, srv := serveAt(t, "127.0.0.1:0")
+	opts := testOptions(addr)
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	opts.ReconnectIdle = true
+
+	p, err := NewPool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.State(); s != StateNew {
+		t.Fatalf("state = %s, want new", s)
+	}
+
+	// Run 之后通知状态变化
+	changed := p.StateChanged()
+	p.Run()
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("StateChanged not closed after Run")
+	}
+	waitState(t, p, StateRunning)
+
+	// 端点不可用时，连接重连失败，连接池降级
+	srv.Stop()
+	waitState(t, p, StateDegraded)
+
+	// 端点恢复后重新可用
+	serveAt(t, addr)
+	waitState(t, p, StateRunning)
+
+	conn, err := p.Acquire(time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 关闭时等待连接被归还
+	closed := make(chan error, 1)
+	go func() {
+		closed <- p.Close(context.Background())
+	}()
+	waitState(t, p, StateDraining)
+	p.Release(conn)
+
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	if s := p.State(); s != StateClosed {
+		t.Fatalf("state = %s after Close, want closed", s)
+	}
+	if err := p.WaitForState(context.Background(), StateRunning); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("WaitForState after Close = %v, want ErrPoolClosed", err)
+	}
+}
+
+func TestStateWithoutReconnectIdle(t *testing.T) {
+	addr, srv := serveAt(t, "127.0.0.1:0")
+	opts := testOptions(addr)
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	p := runPool(t, opts)
+	waitState(t, p, StateRunning)
+
+	// 未开启 ReconnectIdle 时，断开的连接保持 Idle，由 grpc 在下一次调用时重连
+	srv.Stop()
+
+	p.RLock()
+	conn := p.conns[0]
+	p.RUnlock()
+	eventually(t, "conn did not become idle", func() bool { return conn.conn.GetState() == connectivity.Idle })
+
+	time.Sleep(100 * time.Millisecond)
+	if state := conn.conn.GetState(); state != connectivity.Idle {
+		t.Fatalf("conn state = %s, want idle", state)
+	}
+	if s := p.State(); s != StateRunning {
+		t.Fatalf("pool state = %s, want running", s)
+	}
+}
@@ -0,0 +1,145 @@
+package gogrpcpool
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// 等待时间分布的区间上界
+var waitBounds = []time.Duration{
+	time.Millisecond,
+	time.Millisecond * 5,
+	time.Millisecond * 10,
+	time.Millisecond * 25,
+	time.Millisecond * 50,
+	time.Millisecond * 100,
+	time.Millisecond * 250,
+	time.Millisecond * 500,
+	time.Second,
+}
+
+// 连接池的累积计数
+type poolCounter struct {
+	acquired     int64
+	timeouts     int64
+	failures     int64
+	waitNanos    int64
+	waitMax      int64
+	waitBuckets  [10]int64 // 与 waitBounds 对应，最后一个为无上界的区间
+	dials        int64
+	dialFailures int64
+}
+
+// 记录一次取连接的等待
+func (p *Pool) observeWait(conn *Conn, d time.Duration, err error) {
+	switch {
+	case err == nil:
+		p.opts.EventListener.OnAcquire(conn, d)
+	case errors.Is(err, ErrWaitConnReadyTimeout):
+		p.opts.EventListener.OnAcquireTimeout(d)
+	}
+
+	if p.opts.Recorder != nil {
+		p.opts.Recorder.RecordAcquire(p.opts.Name, d, acquireOutcome(err))
+	}
+
+	c := &p.counter
+	switch {
+	case err == nil:
+		atomic.AddInt64(&c.acquired, 1)
+	case errors.Is(err, ErrWaitConnReadyTimeout):
+		atomic.AddInt64(&c.timeouts, 1)
+	default:
+		atomic.AddInt64(&c.failures, 1)
+	}
+
+	atomic.AddInt64(&c.waitNanos, int64(d))
+	for {
+		max := atomic.LoadInt64(&c.waitMax)
+		if int64(d) <= max || atomic.CompareAndSwapInt64(&c.waitMax, max, int64(d)) {
+			break
+		}
+	}
+
+	i := 0
+	for i < len(waitBounds) && d > waitBounds[i] {
+		i++
+	}
+	atomic.AddInt64(&c.waitBuckets[i], 1)
+}
+
+// 记录一次拨号
+func (p *Pool) observeDial(addr string, cost time.Duration, err error) {
+	if p.opts.Recorder != nil {
+		p.opts.Recorder.RecordDial(p.opts.Name, addr, cost, err)
+	}
+
+	atomic.AddInt64(&p.counter.dials, 1)
+	if err != nil {
+		atomic.AddInt64(&p.counter.dialFailures, 1)
+	}
+}
+
+// 连接池状态快照
+// 1. 在持有读锁的情况下生成，快照期间连接集合不会被 reset 或 newConn 修改
+func (p *Pool) Stats() Stats {
+	p.RLock()
+	defer p.RUnlock()
+
+	c := &p.counter
+	now := time.Now()
+	stats := Stats{
+		Name:             p.opts.Name,
+		Target:           p.opts.Target,
+		At:               now,
+		State:            p.State().String(),
+		MinConns:         p.opts.MinConns,
+		MaxConns:         p.opts.MaxConns,
+		MaxIdleConns:     p.opts.MaxIdleConns,
+		MaxRefs:          p.opts.MaxRefs,
+		ConnCount:        atomic.LoadInt32(&p.connCount),
+		ConnIdleCount:    atomic.LoadInt32(&p.connIdleCount),
+		ConnClosingCount: atomic.LoadInt32(&p.connClosingCount),
+		ConnQuota:        atomic.LoadInt32(&p.connQuota),
+		RefCount:         atomic.LoadInt32(&p.refCount),
+		Wait: WaitStats{
+			Acquired: atomic.LoadInt64(&c.acquired),
+			Timeouts: atomic.LoadInt64(&c.timeouts),
+			Failures: atomic.LoadInt64(&c.failures),
+			Total:    time.Duration(atomic.LoadInt64(&c.waitNanos)),
+			Max:      time.Duration(atomic.LoadInt64(&c.waitMax)),
+			Buckets:  []WaitBucket{},
+		},
+		Dials:        atomic.LoadInt64(&c.dials),
+		DialFailures: atomic.LoadInt64(&c.dialFailures),
+		Conns:        []ConnStats{},
+		Localities:   []LocalityStats{},
+	}
+
+	cumulative := int64(0)
+	for i := range c.waitBuckets {
+		cumulative += atomic.LoadInt64(&c.waitBuckets[i])
+		bucket := WaitBucket{Count: cumulative}
+		if i < len(waitBounds) {
+			bucket.UpperBound = waitBounds[i]
+		}
+		stats.Wait.Buckets = append(stats.Wait.Buckets, bucket)
+	}
+
+	localities := map[string]int{}
+	for _, conn := range p.conns {
+		cs := conn.stats(now)
+		stats.Conns = append(stats.Conns, cs)
+
+		i, ok := localities[cs.Locality]
+		if !ok {
+			i = len(stats.Localities)
+			localities[cs.Locality] = i
+			stats.Localities = append(stats.Localities, LocalityStats{Name: cs.Locality, Local: conn.local})
+		}
+		stats.Localities[i].add(conn, cs)
+	}
+
+	return stats
+}
@@ -0,0 +1,53 @@
+package gogrpcpool
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsConcurrent(t *testing.T) {
+	p := runPool(t, testOptions(serve(t)))
+
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				conn, err := p.Acquire(100 * time.Millisecond)
+				if err != nil {
+					continue
+				}
+				p.Release(conn)
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(200 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		stats := p.Stats()
+		if stats.RefCount < 0 || stats.ConnCount > stats.MaxConns {
+			t.Fatalf("inconsistent stats %+v", stats)
+		}
+		for _, cs := range stats.Conns {
+			if cs.Ref < 0 || cs.Ref > cs.MaxRef {
+				t.Fatalf("inconsistent conn stats %+v", cs)
+			}
+		}
+		p.Describe()
+	}
+	close(stop)
+	wg.Wait()
+
+	if stats := p.Stats(); stats.Wait.Acquired == 0 {
+		t.Fatal("no conn acquired")
+	}
+}
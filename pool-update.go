@@ -0,0 +1,100 @@
+package gogrpcpool
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+)
+
+// 在运行时调整连接池的限制
+// 1. 只应用 MinConns、MaxConns、MaxIdleConns、MaxRefs、NewConnRate，其余字段被忽略，校验规则与 Options.Validate 相同
+// 2. 调整 MaxConns 时同步调整连接配额，并按照新的容量替换就绪队列
+// 3. 缩小 MaxConns 时，超出的连接按照引用数从少到多被排空，待引用数归零后由 idleConnManager 关闭
+// 4. 调整 MaxRefs 时同步修改已建立的连接，引用数已超出的连接在引用数回落之前不会再被取用
+// 5. 缩小 MaxIdleConns 时，多出的空闲连接仍按照 CloseWait 由 idleConnManager 关闭
+// 6. 调大 MinConns 时立即在后台补足连接
+func (p *Pool) Update(opts Options) error {
+	if err := errors.Join(opts.limitErrors()...); err != nil {
+		return err
+	}
+
+	if opts.NewConnRate < DefaultNewConnRate {
+		opts.NewConnRate = DefaultNewConnRate
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	oldMaxConns := p.maxConns()
+	atomic.AddInt32(&p.connQuota, opts.MaxConns-oldMaxConns)
+	atomic.StoreInt32(&p.opts.MinConns, opts.MinConns)
+	atomic.StoreInt32(&p.opts.MaxConns, opts.MaxConns)
+	atomic.StoreInt32(&p.opts.MaxIdleConns, opts.MaxIdleConns)
+	atomic.StoreInt32(&p.opts.MaxRefs, opts.MaxRefs)
+	atomic.StoreInt32(&p.opts.NewConnRate, opts.NewConnRate)
+
+	for _, conn := range p.conns {
+		atomic.StoreInt32(&conn.refMax, opts.MaxRefs)
+	}
+
+	if opts.MaxConns != oldMaxConns {
+		p.resizeReadyQueue(opts.MaxConns)
+	}
+
+	drained := p.drainSurplusConns(opts.MaxConns)
+	p.warm()
+
+	p.logger.Info("update pool limits",
+		"minConns", opts.MinConns,
+		"maxConns", opts.MaxConns,
+		"maxIdleConns", opts.MaxIdleConns,
+		"maxRefs", opts.MaxRefs,
+		"newConnRate", opts.NewConnRate,
+		"drained", drained,
+	)
+	return nil
+}
+
+// 排空超出 max 的连接，返回被排空的连接数，需在持有锁的情况下调用
+func (p *Pool) drainSurplusConns(max int32) int {
+	alive := []*Conn{}
+	for _, conn := range p.conns {
+		if !conn.closing.Load() {
+			alive = append(alive, conn)
+		}
+	}
+
+	surplus := len(alive) - int(max)
+	if surplus <= 0 {
+		return 0
+	}
+
+	sort.SliceStable(alive, func(i, j int) bool {
+		return alive[i].chkRef() < alive[j].chkRef()
+	})
+	for _, conn := range alive[:surplus] {
+		conn.drain()
+		p.opts.EventListener.OnConnClosing(conn)
+	}
+	return surplus
+}
+
+// 当前的最少连接数
+func (p *Pool) minConns() int32 {
+	return atomic.LoadInt32(&p.opts.MinConns)
+}
+
+// 当前的最大连接数
+func (p *Pool) maxConns() int32 {
+	return atomic.LoadInt32(&p.opts.MaxConns)
+}
+
+// 当前的最大空闲连接数
+func (p *Pool) maxIdleConns() int32 {
+	return atomic.LoadInt32(&p.opts.MaxIdleConns)
+}
+
+// 当前每个连接的最大引用数
+func (p *Pool) maxRefs() int32 {
+	return atomic.LoadInt32(&p.opts.MaxRefs)
+}
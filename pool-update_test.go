@@ -0,0 +1,147 @@
+package gogrpcpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateLimits(t *testing.T) {
+	p := runPool(t, testOptions(serve(t)))
+
+	if err := p.Update(Options{MaxConns: 2, MaxIdleConns: 3, MaxRefs: 0}); !errors.Is(err, ErrInvalidOption) {
+		t.Fatalf("Update = %v, want ErrInvalidOption", err)
+	}
+
+	// 扩容后可以取得更多的连接
+	if err := p.Update(Options{MaxConns: 6, MaxIdleConns: 1, MaxRefs: 1}); err != nil {
+		t.Fatal(err)
+	}
+	held := []*Conn{}
+	for i := 0; i < 6; i++ {
+		conn, err := p.Acquire(time.Second)
+		if err != nil {
+			t.Fatalf("Acquire %d: %v", i, err)
+		}
+		held = append(held, conn)
+	}
+	if _, err := p.Acquire(20 * time.Millisecond); !errors.Is(err, ErrWaitConnReadyTimeout) {
+		t.Fatalf("Acquire beyond MaxConns = %v, want timeout", err)
+	}
+
+	// 缩容后多出的连接被排空，配额随连接关闭归还
+	if err := p.Update(Options{MaxConns: 2, MaxIdleConns: 1, MaxRefs: 1}); err != nil {
+		t.Fatal(err)
+	}
+	stats := p.Stats()
+	closing := 0
+	for _, cs := range stats.Conns {
+		if cs.State == ConnStateClosing {
+			closing += 1
+		}
+	}
+	if closing != 4 || stats.ConnQuota != -4 {
+		t.Fatalf("closing = %d, quota = %d, want 4 and -4", closing, stats.ConnQuota)
+	}
+
+	for _, conn := range held {
+		p.Release(conn)
+	}
+	p.reset()
+	if stats := p.Stats(); stats.ConnCount != 2 || stats.ConnQuota != 0 {
+		t.Fatalf("conns = %d, quota = %d, want 2 and 0", stats.ConnCount, stats.ConnQuota)
+	}
+}
+
+func TestUpdateResizeUnderLoad(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 4
+	opts.MaxRefs = 1
+	p := runPool(t, opts)
+
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				conn, err := p.Acquire(10 * time.Millisecond)
+				if err != nil {
+					continue
+				}
+				p.Release(conn)
+			}
+		}()
+	}
+
+	for i := 0; i < 300; i++ {
+		if err := p.Update(Options{MaxConns: int32(2 + i%3*2), MaxIdleConns: 1, MaxRefs: 1}); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+
+	if err := p.Update(Options{MaxConns: 4, MaxIdleConns: 1, MaxRefs: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 每个未关闭的连接都能被取得
+	held := []*Conn{}
+	for {
+		conn, err := p.Acquire(200 * time.Millisecond)
+		if err != nil {
+			break
+		}
+		held = append(held, conn)
+	}
+	for _, cs := range p.Stats().Conns {
+		if cs.State != ConnStateClosing && cs.Ref != 1 {
+			t.Errorf("conn %d was never acquired: %+v", cs.ID, cs)
+		}
+	}
+	if len(held) == 0 {
+		t.Fatal("no conn acquired")
+	}
+	for _, conn := range held {
+		p.Release(conn)
+	}
+}
+
+func TestResizeWhilePushing(t *testing.T) {
+	opts := testOptions(serve(t))
+	opts.MaxConns = 1
+	opts.MaxIdleConns = 1
+	p, err := NewPool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close(context.Background())
+
+	conn := &Conn{pool: p}
+	for i := 0; i < 50; i++ {
+		// run 在就绪队列被替换之前取得了旧的就绪队列，替换之后才推入
+		tunnel, q := conn.tunnel()
+		if err := p.Update(Options{MaxConns: int32(2 - i%2), MaxIdleConns: 1, MaxRefs: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if !conn.push(tunnel, q, p.done) {
+			t.Fatal("push reported pool closed")
+		}
+
+		// 连接要么没有被推入旧队列，要么被移除了就绪状态，run 会将它重新推入新的就绪队列
+		if conn.readying.Load() {
+			t.Fatalf("iteration %d: conn left ready in a discarded queue", i)
+		}
+	}
+}
@@ -2,8 +2,9 @@ package gogrpcpool
 
 import (
 	"context"
-	"log"
+	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"google.golang.org/grpc"
@@ -14,106 +15,201 @@ import (
 type Pool struct {
 	sync.RWMutex
 
-	opts  Options
-	conns []*Conn
+	opts      Options
+	conns     []*Conn
+	endpoints []Endpoint // 端点发现得到的端点集合，未配置端点发现时为空
+	ring      *hashRing  // 端点的一致性哈希环，用于 AcquireByKey
+
+	stopWatch context.CancelFunc // 停止端点发现
+	closed    int32              // 连接池是否已关闭，关闭后不再接受新的取连接请求
+	done      chan struct{}      // 连接池关闭时被关闭，通知等待中的取连接请求及连接池启动的 goroutine
+	wg        sync.WaitGroup     // 连接池启动的 goroutine，关闭时等待它们全部退出
+	warmCh    chan struct{}      // 通知后台补足 MinConns
+
+	stateMu      sync.Mutex
+	state        PoolState     // 生命周期状态
+	stateChanged chan struct{} // 状态变化时被关闭并替换
+	logger       Logger        // 附加了 pool、target 字段的日志
 
 	connQuota        int32 // 最大连接数配额，新建连接时减一，关闭连接时加一
 	connCount        int32 // 当前已建立连接数，用来做真实连接数计算
 	connIdleCount    int32 // 当前空闲连接数
 	connClosingCount int32 // 正处于关闭中状态的连接数
 
-	refCount    int32      // 连接总的引用计数
-	readyTunnel chan *Conn // 就绪连接通道，连接池从这里去就绪的连接，就绪的连接主动将自己推入这个通道
+	counter poolCounter // 取连接、拨号的累积计数
+
+	refCount int32                      // 连接总的引用计数
+	queue    atomic.Pointer[readyQueue] // 就绪队列，连接池从这里取就绪的连接，就绪的连接主动将自己推入，本地连接优先被取用
 }
 
 // 实例化连接池
-func NewPool(opts Options) *Pool {
-	if opts.Target == "" {
-		log.Fatalf("new Pool Failed: %v", ErrTargetNotAvailable)
+// 1. 先校验 opts，所有不合法的字段汇总为一个错误返回，见 Options.Validate
+// 2. 未设置的字段使用默认值，默认值见 Options 的字段说明
+func NewPool(opts Options) (*Pool, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
 	}
 
 	pool := &Pool{
 		opts: Options{
+			Name:             opts.Name,
+			ExpvarName:       opts.ExpvarName,
 			Debug:            opts.Debug,
 			DescribeDuration: opts.DescribeDuration,
 			CheckPeriod:      opts.CheckPeriod,
 			CloseWait:        opts.CloseWait,
 			ConnTimeOut:      opts.ConnTimeOut,
 			ConnBlock:        opts.ConnBlock,
+			ReconnectIdle:    opts.ReconnectIdle,
 			Target:           opts.Target,
 			Dopts:            []grpc.DialOption{},
+			MinConns:         opts.MinConns,
 			MaxConns:         opts.MaxConns,
 			MaxIdleConns:     opts.MaxIdleConns,
 			MaxRefs:          opts.MaxRefs,
 			NewConnRate:      opts.NewConnRate,
+			Discovery:        opts.Discovery,
+			Locality:         opts.Locality,
+			Recorder:         opts.Recorder,
+			Tracer:           opts.Tracer,
+			EventListener:    opts.EventListener,
+			Logger:           opts.Logger,
 		},
-		readyTunnel: make(chan *Conn, opts.MaxConns),
-		connQuota:   opts.MaxConns,
-		conns:       []*Conn{},
+		done:         make(chan struct{}),
+		stateChanged: make(chan struct{}),
+		warmCh:       make(chan struct{}, 1),
+		connQuota:    opts.MaxConns,
+		conns:        []*Conn{},
+		endpoints:    []Endpoint{},
+		ring:         newHashRing(nil),
 	}
+	pool.queue.Store(newReadyQueue(opts.MaxConns))
 
-	if pool.opts.CheckPeriod < time.Second*3 {
-		pool.opts.CheckPeriod = time.Second * 3
+	// 未配置端点发现时，哈希环上只有 Target 一个端点
+	if opts.Discovery == nil {
+		pool.ring = newHashRing([]Endpoint{{Addr: opts.Target}})
+	}
+
+	if pool.opts.CheckPeriod < DefaultCheckPeriod {
+		pool.opts.CheckPeriod = DefaultCheckPeriod
 	}
 
 	if pool.opts.CloseWait < time.Second {
-		pool.opts.CloseWait = time.Second * 20
+		pool.opts.CloseWait = DefaultCloseWait
+	}
+
+	if pool.opts.ConnTimeOut <= time.Duration(0) {
+		pool.opts.ConnTimeOut = DefaultConnTimeOut
 	}
 
-	if pool.opts.NewConnRate < 2 {
-		pool.opts.NewConnRate = 2
+	if pool.opts.NewConnRate < DefaultNewConnRate {
+		pool.opts.NewConnRate = DefaultNewConnRate
+	}
+
+	if pool.opts.Logger == nil {
+		pool.opts.Logger = NewStdLogger(os.Stderr, pool.opts.Debug)
+	}
+	pool.logger = withFields(pool.opts.Logger, "pool", pool.opts.Name, "target", pool.opts.Target)
+
+	if pool.opts.EventListener == nil {
+		pool.opts.EventListener = NopEventListener{}
 	}
 
 	if pool.opts.DescribeDuration <= time.Duration(0) {
-		pool.opts.DescribeDuration = time.Second * 1
+		pool.opts.DescribeDuration = DefaultDescribeDuration
 	}
 
 	pool.opts.Dopts = append(pool.opts.Dopts, opts.Dopts...)
 
-	return pool
+	register(pool)
+	pool.publishExpvar()
+	return pool, nil
 }
 
 // 启动
+// 1. 启动的 goroutine 都随连接池关闭而退出，已关闭的连接池不再启动
 func (p *Pool) Run() {
-	p.initConns()
+	if p.isClosed() {
+		return
+	}
+	p.compareAndSetState(StateNew, StateStarting)
+
+	// 配置了端点发现时，连接在首次获取到端点后进行初始化
+	if p.opts.Discovery != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		p.stopWatch = cancel
+		p.goFunc(func() { p.watchEndpoints(ctx) })
+	} else {
+		p.initConns(0)
+	}
 
 	if p.opts.Debug {
-		go p.DescribeTimer()
+		p.goFunc(p.DescribeTimer)
 	}
 
-	go p.idleConnManager()
+	p.goFunc(p.idleConnManager)
+	p.goFunc(p.minConnsManager)
+	p.warm()
 }
 
-// 关闭连接
-func (p *Pool) Close() {
-	p.Lock()
-	defer p.Unlock()
+// 启动一个随连接池关闭而退出的 goroutine
+func (p *Pool) goFunc(fn func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		fn()
+	}()
+}
 
-	// 设置执行超时
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancel()
+// 关闭连接池
+// 1. 首先拒绝新的取连接请求，等待中的请求返回 ErrPoolClosed
+// 2. 等待已取出的连接被归还，直到 ctx 结束
+// 3. 关闭所有连接，ctx 结束时仍未归还的引用被放弃，返回 *CloseError
+// 4. 等待连接池启动的所有 goroutine 退出，包括端点发现、空闲连接管理、调试输出以及各连接的 run
+// 5. 重复关闭返回 ErrPoolClosed
+func (p *Pool) Close(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&p.closed, 0, 1) {
+		return ErrPoolClosed
+	}
+	close(p.done)
+	p.setState(StateDraining)
+
+	unregister(p)
+	p.unpublishExpvar()
+
+	if p.stopWatch != nil {
+		p.stopWatch()
+	}
 
 	// 标记所有连接为关闭状态
+	p.Lock()
 	for _, conn := range p.conns {
-		conn.setClosing(true)
+		if !conn.closing.Load() {
+			conn.drain()
+			p.opts.EventListener.OnConnClosing(conn)
+		}
 	}
+	p.Unlock()
 
-	// 关闭就绪通道
-	close(p.readyTunnel)
+	// 定时循环检查连接是否被归还完毕
+	var err error
+	tricker := time.NewTicker(time.Millisecond * 10)
+	defer tricker.Stop()
 
-	// 定时循环检查连接是否被回收完毕
-	tricker := time.NewTicker(time.Second * 2)
+wait:
 	for p.chkConnReferd() > 0 {
 		select {
 		case <-ctx.Done():
-			return
+			err = &CloseError{Abandoned: p.chkConnReferd(), Err: ctx.Err()}
+			break wait
 		case <-tricker.C:
 		}
 	}
 
 	// 关闭所有的连接
+	p.Lock()
 	for _, conn := range p.conns {
-		conn.close()
+		p.closeConn(conn)
 	}
 
 	// 清空连接池
@@ -124,16 +220,31 @@ func (p *Pool) Close() {
 	p.resetIdleConnCount(0)
 	p.resetClosingConnCount(0)
 	p.resetConnRefCount(0)
+	p.Unlock()
+
+	// 端点发现的回调需要持有锁，在释放锁之后等待
+	p.wg.Wait()
+	p.setState(StateClosed)
+
+	if err != nil {
+		p.logger.Warn("close pool with abandoned leases", "error", err)
+	}
+	return err
 }
 
-// 初始化连接，按照最大空闲数建立连接
-func (p *Pool) initConns() {
-	for i := int32(0); i < p.opts.MaxIdleConns; i++ {
+// 连接池是否已关闭
+func (p *Pool) isClosed() bool {
+	return atomic.LoadInt32(&p.closed) == 1
+}
+
+// 初始化连接，按照最大空闲数建立连接，dialed 为已经建立的连接数
+func (p *Pool) initConns(dialed int32) {
+	for i := dialed; i < p.maxIdleConns(); i++ {
 		if !p.askConnQuota() {
 			continue
 		}
 
-		if _, err := p.newConn(p.opts.ConnBlock); err != nil {
+		if _, err := p.newConn(context.Background(), p.opts.ConnBlock); err != nil {
 			p.rbkConnQuota()
 			continue
 		}
@@ -141,22 +252,93 @@ func (p *Pool) initConns() {
 }
 
 // 新建连接
-func (p *Pool) newConn(block bool) (*Conn, error) {
+// 1. ctx 仅用于链路追踪，拨号的超时时间由 ConnTimeOut 决定
+func (p *Pool) newConn(ctx context.Context, block bool) (*Conn, error) {
 	p.Lock()
 	defer p.Unlock()
 
-	st := time.Now().UTC().UnixMilli()
-	conn, err := p.opts.Dial(p.readyTunnel, block)
-	if p.opts.Debug {
-		log.Printf("newConn: cost %v ms", time.Now().UnixMilli()-st)
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	ep, err := p.pickEndpoint()
+	if err != nil {
+		return nil, err
 	}
+	return p.dialEndpoint(ctx, ep, block)
+}
+
+// 在指定端点上新建连接
+func (p *Pool) newEndpointConn(ctx context.Context, ep Endpoint, block bool) (*Conn, error) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.isClosed() {
+		return nil, ErrPoolClosed
+	}
+
+	return p.dialEndpoint(ctx, ep, block)
+}
+
+// 拨号并将连接加入连接池，需在持有锁的情况下调用
+func (p *Pool) dialEndpoint(ctx context.Context, ep Endpoint, block bool) (conn *Conn, err error) {
+	end := p.traceDial(ctx, ep.Addr)
+	defer func() {
+		end(conn, err)
+	}()
+
+	st := time.Now()
+	conn, err = p.opts.dialConn(ep, block)
+	cost := time.Since(st)
+	p.observeDial(ep.Addr, cost, err)
 
 	if err != nil {
+		p.logger.Warn("dial conn failed", "addr", ep.Addr, "cost", cost, "error", err)
+		p.opts.EventListener.OnDialFailed(ep.Addr, cost, err)
 		return nil, err
 	}
+	p.logger.Debug("dial conn", "addr", ep.Addr, "conn", conn.id, "cost", cost)
+	// 本地连接推入本地就绪通道
+	conn.local = p.opts.Locality.match(ep)
+	conn.pool = p
+	p.goFunc(conn.run)
 
 	p.conns = append(p.conns, conn)
 	p.addConnCount()
 	p.addIdleConnCount()
+	p.opts.EventListener.OnConnDialed(conn, cost)
+	p.refreshState()
 	return conn, nil
 }
+
+// 连接池名称
+func (p *Pool) Name() string {
+	return p.opts.Name
+}
+
+// 关闭连接并记录连接的存活时长
+func (p *Pool) closeConn(conn *Conn) error {
+	if p.opts.Recorder != nil {
+		p.opts.Recorder.RecordConnClosed(p.opts.Name, conn.endpoint.Addr, time.Since(conn.createdAt))
+	}
+	err := conn.close()
+	conn.endLeases()
+	p.opts.EventListener.OnConnClosed(conn)
+	return err
+}
+
+// 开始追踪取连接
+func (p *Pool) traceAcquire(ctx context.Context) (context.Context, func(*Conn, error)) {
+	if p.opts.Tracer == nil {
+		return ctx, func(*Conn, error) {}
+	}
+	return p.opts.Tracer.TraceAcquire(ctx, p.opts.Name)
+}
+
+// 开始追踪拨号
+func (p *Pool) traceDial(ctx context.Context, addr string) func(*Conn, error) {
+	if p.opts.Tracer == nil {
+		return func(*Conn, error) {}
+	}
+	return p.opts.Tracer.TraceDial(ctx, p.opts.Name, addr)
+}
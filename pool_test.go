@@ -0,0 +1,72 @@
+package gogrpcpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/biandoucheng/go-grpc-pool/internal/pooltest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 启动一个本地 grpc 服务，测试结束时停止
+func serve(t *testing.T) string {
+	return pooltest.Serve(t)
+}
+
+// 在指定地址启动一个本地 grpc 服务，测试结束时停止
+func serveAt(t *testing.T, addr string) (string, *grpc.Server) {
+	return pooltest.ServeAt(t, addr)
+}
+
+// 测试用的连接池配置，日志被丢弃
+func testOptions(target string) Options {
+	return Options{
+		Target:       target,
+		Dopts:        []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+		MaxConns:     4,
+		MaxIdleConns: 2,
+		MaxRefs:      2,
+		Logger:       nopLogger{},
+	}
+}
+
+// 启动连接池，测试结束时关闭
+func runPool(t *testing.T, opts Options) *Pool {
+	t.Helper()
+
+	p, err := NewPool(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Run()
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		p.Close(ctx)
+	})
+	return p
+}
+
+type nopLogger = pooltest.NopLogger
+
+// 不主动更新端点的端点发现，测试中直接调用 updateEndpoints
+type manualDiscovery struct{}
+
+func (manualDiscovery) Watch(ctx context.Context, update func([]Endpoint)) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// 启动 n 个本地 grpc 服务，返回对应的端点
+func serveEndpoints(t *testing.T, n int) []Endpoint {
+	t.Helper()
+
+	eps := make([]Endpoint, n)
+	for i := range eps {
+		eps[i] = Endpoint{Addr: serve(t)}
+	}
+	return eps
+}
@@ -0,0 +1,80 @@
+// 连接池调试页面
+//
+// Handler 展示进程内所有已注册连接池（gogrpcpool.Pools）的 Stats 快照，类似 net/http/pprof、expvar，
+// 可以挂载在管理端口上供排查问题：
+//
+//	mux.Handle("/debug/grpcpool", pooldebug.Handler())
+//
+// 默认输出可读的 HTML 页面，请求参数 format=json 或者请求头 Accept: application/json 时输出 JSON
+package pooldebug
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+)
+
+// 调试页面的处理器
+func Handler() http.Handler {
+	return http.HandlerFunc(serve)
+}
+
+func serve(w http.ResponseWriter, r *http.Request) {
+	stats := []gogrpcpool.Stats{}
+	for _, pool := range gogrpcpool.Pools() {
+		stats = append(stats, pool.Stats())
+	}
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(stats)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := page.Execute(w, stats); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+var page = template.Must(template.New("pooldebug").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return d.Truncate(time.Millisecond).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>grpc pools</title>
+<style>
+body { font-family: sans-serif; font-size: 13px; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+th { background: #eee; }
+</style>
+</head>
+<body>
+<p>{{len .}} pool(s), <a href="?format=json">json</a></p>
+{{range .}}
+<h2>{{if .Name}}{{.Name}}{{else}}(unnamed){{end}} {{.Target}} [{{.State}}]</h2>
+<table>
+<tr><th>conns</th><th>idle</th><th>closing</th><th>quota</th><th>leases</th><th>max conns</th><th>max idle</th><th>max refs</th></tr>
+<tr><td>{{.ConnCount}}</td><td>{{.ConnIdleCount}}</td><td>{{.ConnClosingCount}}</td><td>{{.ConnQuota}}</td><td>{{.RefCount}}</td><td>{{.MaxConns}}</td><td>{{.MaxIdleConns}}</td><td>{{.MaxRefs}}</td></tr>
+</table>
+<table>
+<tr><th>acquired</th><th>timeouts</th><th>failures</th><th>total wait</th><th>max wait</th><th>dials</th><th>dial failures</th></tr>
+<tr><td>{{.Wait.Acquired}}</td><td>{{.Wait.Timeouts}}</td><td>{{.Wait.Failures}}</td><td>{{ms .Wait.Total}}</td><td>{{ms .Wait.Max}}</td><td>{{.Dials}}</td><td>{{.DialFailures}}</td></tr>
+</table>
+<table>
+<tr><th>id</th><th>addr</th><th>locality</th><th>state</th><th>leases</th><th>ready</th><th>connectivity</th><th>age</th><th>idle</th><th>leases served</th><th>rpcs</th><th>rpc failures</th><th>mean latency</th><th>max latency</th></tr>
+{{range .Conns}}<tr><td>{{.ID}}</td><td>{{.Addr}}</td><td>{{.Locality}}</td><td>{{.State}}</td><td>{{.Ref}}/{{.MaxRef}}</td><td>{{.Ready}}</td><td>{{.Connectivity}}</td><td>{{ms .Age}}</td><td>{{ms .Idle}}</td><td>{{.Leases}}</td><td>{{.RPC.Total}}</td><td>{{.RPC.Failures}}</td><td>{{.RPC.Mean}}</td><td>{{.RPC.Max}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,39 @@
+package gogrpcpool
+
+import (
+	"errors"
+	"time"
+)
+
+// 取连接的结果
+type AcquireOutcome string
+
+const (
+	AcquireOK      AcquireOutcome = "ok"
+	AcquireTimeout AcquireOutcome = "timeout"
+	AcquireClosed  AcquireOutcome = "closed"
+	AcquireError   AcquireOutcome = "error"
+)
+
+// 根据取连接返回的错误判断结果
+func acquireOutcome(err error) AcquireOutcome {
+	switch {
+	case err == nil:
+		return AcquireOK
+	case errors.Is(err, ErrWaitConnReadyTimeout):
+		return AcquireTimeout
+	case errors.Is(err, ErrPoolClosed):
+		return AcquireClosed
+	default:
+		return AcquireError
+	}
+}
+
+// 指标记录器
+// 1. 连接池在取连接、拨号、关闭连接时同步调用，实现需要是并发安全且足够轻量的
+// 2. pool 为连接池名称，即 Options.Name
+type Recorder interface {
+	RecordAcquire(pool string, wait time.Duration, outcome AcquireOutcome)
+	RecordDial(pool string, addr string, cost time.Duration, err error)
+	RecordConnClosed(pool string, addr string, lifetime time.Duration)
+}
@@ -0,0 +1,40 @@
+package gogrpcpool
+
+import "sync"
+
+// 连接池注册表，NewPool 创建的连接池自动注册，Close 时注销
+// 调试页面、expvar 等通过注册表获取进程内的所有连接池
+var registry = struct {
+	sync.RWMutex
+	pools []*Pool
+}{pools: []*Pool{}}
+
+// 注册连接池
+func register(p *Pool) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.pools = append(registry.pools, p)
+}
+
+// 注销连接池
+func unregister(p *Pool) {
+	registry.Lock()
+	defer registry.Unlock()
+
+	for i, pool := range registry.pools {
+		if pool == p {
+			registry.pools = append(registry.pools[:i], registry.pools[i+1:]...)
+			return
+		}
+	}
+}
+
+// 查询所有已注册的连接池，按照注册顺序排列
+func Pools() []*Pool {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	pools := make([]*Pool, len(registry.pools))
+	copy(pools, registry.pools)
+	return pools
+}
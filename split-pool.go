@@ -0,0 +1,152 @@
+package gogrpcpool
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrNoSplit      = errors.New("no split available")
+	ErrSplitUnknown = errors.New("split not found")
+)
+
+// 分流目标
+type Split struct {
+	Name   string // 分流名称，例如 stable、canary
+	Pool   *Pool  // 分流目标的连接池
+	Weight int32  // 分流权重，为 0 时不分配流量
+}
+
+// 分流统计
+type SplitStats struct {
+	Name     string
+	Weight   int32
+	Acquired int64 // 成功取得连接的次数
+	Failed   int64 // 取连接失败的次数
+}
+
+// 按权重分流的连接池
+// 1. 包装多个 Pool，每次取连接时按照权重选择其中一个，用于金丝雀发布等场景
+// 2. 使用平滑加权轮询选择目标，流量比例严格按照权重分配
+// 3. 权重可以在运行时调整，调整立即生效
+// 4. SplitPool 不负责各个 Pool 的启动和关闭
+type SplitPool struct {
+	sync.Mutex
+	splits []*split
+}
+
+type split struct {
+	Split
+	current  int64 // 平滑加权轮询的当前权重
+	acquired int64
+	failed   int64
+}
+
+// 实例化分流连接池
+func NewSplitPool(splits ...Split) (*SplitPool, error) {
+	if len(splits) == 0 {
+		return nil, ErrNoSplit
+	}
+
+	sp := &SplitPool{splits: []*split{}}
+	names := map[string]bool{}
+	for _, s := range splits {
+		if s.Pool == nil {
+			return nil, fmt.Errorf("split %q: pool is nil", s.Name)
+		}
+		if s.Weight < 0 {
+			return nil, fmt.Errorf("split %q: negative weight %d", s.Name, s.Weight)
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("split %q: duplicate name", s.Name)
+		}
+		names[s.Name] = true
+		sp.splits = append(sp.splits, &split{Split: s})
+	}
+	return sp, nil
+}
+
+// 按权重选取一个连接池并寻求可用的连接
+func (sp *SplitPool) Acquire(d time.Duration) (*Conn, error) {
+	s := sp.pick()
+	if s == nil {
+		return nil, ErrNoSplit
+	}
+
+	conn, err := s.Pool.Acquire(d)
+	if err != nil {
+		atomic.AddInt64(&s.failed, 1)
+		return nil, err
+	}
+	atomic.AddInt64(&s.acquired, 1)
+	return conn, nil
+}
+
+// 释放连接，连接会被归还到它所属的连接池
+// 1. 不属于任何连接池的连接，例如通过 Options.Dial 建立的连接，返回 ErrConnNotPooled
+func (sp *SplitPool) Release(conn *Conn) error {
+	return releaseToPool(conn)
+}
+
+// 调整分流权重
+func (sp *SplitPool) SetWeight(name string, weight int32) error {
+	if weight < 0 {
+		return fmt.Errorf("split %q: negative weight %d", name, weight)
+	}
+
+	sp.Lock()
+	defer sp.Unlock()
+
+	for _, s := range sp.splits {
+		if s.Name == name {
+			s.Weight = weight
+			s.current = 0
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSplitUnknown, name)
+}
+
+// 分流统计
+func (sp *SplitPool) Stats() []SplitStats {
+	sp.Lock()
+	defer sp.Unlock()
+
+	stats := []SplitStats{}
+	for _, s := range sp.splits {
+		stats = append(stats, SplitStats{
+			Name:     s.Name,
+			Weight:   s.Weight,
+			Acquired: atomic.LoadInt64(&s.acquired),
+			Failed:   atomic.LoadInt64(&s.failed),
+		})
+	}
+	return stats
+}
+
+// 平滑加权轮询选取分流目标，所有权重都为 0 时返回 nil
+func (sp *SplitPool) pick() *split {
+	sp.Lock()
+	defer sp.Unlock()
+
+	total := int64(0)
+	var best *split
+	for _, s := range sp.splits {
+		if s.Weight <= 0 {
+			continue
+		}
+		s.current += int64(s.Weight)
+		total += int64(s.Weight)
+		if best == nil || s.current > best.current {
+			best = s
+		}
+	}
+
+	if best != nil {
+		best.current -= total
+	}
+	return best
+}
@@ -0,0 +1,141 @@
+package gogrpcpool
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 连续选取 n 次的分流名称
+func picks(sp *SplitPool, n int) []string {
+	names := []string{}
+	for i := 0; i < n; i++ {
+		if s := sp.pick(); s != nil {
+			names = append(names, s.Name)
+		} else {
+			names = append(names, "")
+		}
+	}
+	return names
+}
+
+func TestSplitSmoothWeighted(t *testing.T) {
+	sp, err := NewSplitPool(
+		Split{Name: "a", Pool: closedPool(t), Weight: 5},
+		Split{Name: "b", Pool: closedPool(t), Weight: 1},
+		Split{Name: "c", Pool: closedPool(t), Weight: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 平滑加权轮询不会连续集中选取权重大的目标
+	if got := strings.Join(picks(sp, 7), ""); got != "aabacaa" {
+		t.Fatalf("picks = %s, want aabacaa", got)
+	}
+
+	counts := map[string]int{}
+	for _, name := range picks(sp, 700) {
+		counts[name] += 1
+	}
+	if counts["a"] != 500 || counts["b"] != 100 || counts["c"] != 100 {
+		t.Fatalf("counts = %v, want 500/100/100", counts)
+	}
+}
+
+func TestSplitSetWeight(t *testing.T) {
+	sp, err := NewSplitPool(
+		Split{Name: "stable", Pool: closedPool(t), Weight: 1},
+		Split{Name: "canary", Pool: closedPool(t), Weight: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 调整后立即生效
+	if err := sp.SetWeight("canary", 0); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range picks(sp, 10) {
+		if name != "stable" {
+			t.Fatalf("picked %s with weight 0", name)
+		}
+	}
+
+	if err := sp.SetWeight("canary", 3); err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, name := range picks(sp, 40) {
+		counts[name] += 1
+	}
+	if counts["stable"] != 10 || counts["canary"] != 30 {
+		t.Fatalf("counts = %v, want stable 10 and canary 30", counts)
+	}
+
+	if err := sp.SetWeight("stable", 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.SetWeight("canary", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sp.Acquire(time.Millisecond); !errors.Is(err, ErrNoSplit) {
+		t.Fatalf("Acquire with all weights 0 = %v, want ErrNoSplit", err)
+	}
+
+	if err := sp.SetWeight("unknown", 1); !errors.Is(err, ErrSplitUnknown) {
+		t.Fatalf("SetWeight(unknown) = %v, want ErrSplitUnknown", err)
+	}
+	if err := sp.SetWeight("stable", -1); err == nil {
+		t.Fatal("SetWeight with negative weight succeeded")
+	}
+}
+
+func TestSplitStats(t *testing.T) {
+	sp, err := NewSplitPool(
+		Split{Name: "stable", Pool: runPool(t, testOptions(serve(t))), Weight: 1},
+		Split{Name: "canary", Pool: closedPool(t), Weight: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 4; i++ {
+		conn, err := sp.Acquire(time.Second)
+		if err != nil {
+			if !errors.Is(err, ErrPoolClosed) {
+				t.Fatalf("Acquire = %v, want ErrPoolClosed from canary", err)
+			}
+			continue
+		}
+		if err := sp.Release(conn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]SplitStats{
+		"stable": {Name: "stable", Weight: 1, Acquired: 2},
+		"canary": {Name: "canary", Weight: 1, Failed: 2},
+	}
+	for _, s := range sp.Stats() {
+		if s != want[s.Name] {
+			t.Fatalf("stats = %+v, want %+v", s, want[s.Name])
+		}
+	}
+}
+
+func TestSplitReleaseUnpooled(t *testing.T) {
+	sp, err := NewSplitPool(Split{Name: "stable", Pool: closedPool(t), Weight: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 未加入连接池的连接，例如通过 Options.Dial 建立的连接
+	if err := sp.Release(&Conn{}); !errors.Is(err, ErrConnNotPooled) {
+		t.Fatalf("Release = %v, want ErrConnNotPooled", err)
+	}
+	if err := sp.Release(nil); !errors.Is(err, ErrConnNotPooled) {
+		t.Fatalf("Release(nil) = %v, want ErrConnNotPooled", err)
+	}
+}
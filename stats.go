@@ -0,0 +1,94 @@
+package gogrpcpool
+
+import (
+	"fmt"
+	"time"
+)
+
+// 连接池状态快照
+type Stats struct {
+	Name   string    // 连接池名称
+	Target string    // grpc 地址，配置了端点发现时为空
+	At     time.Time // 快照时间
+	State  string    // 生命周期状态，见 PoolState
+
+	MinConns     int32
+	MaxConns     int32
+	MaxIdleConns int32
+	MaxRefs      int32
+
+	ConnCount        int32 // 已建立连接数
+	ConnIdleCount    int32 // 空闲连接数
+	ConnClosingCount int32 // 关闭中的连接数
+	ConnQuota        int32 // 剩余的连接配额
+	RefCount         int32 // 连接总的引用计数
+
+	Wait         WaitStats // 取连接的等待统计
+	Dials        int64     // 拨号次数
+	DialFailures int64     // 拨号失败次数
+
+	Conns      []ConnStats
+	Localities []LocalityStats
+}
+
+// 取连接的等待统计
+type WaitStats struct {
+	Acquired int64         // 成功取得连接的次数
+	Timeouts int64         // 等待超时的次数
+	Failures int64         // 其他原因失败的次数
+	Total    time.Duration // 总的等待时间
+	Max      time.Duration // 最长的等待时间
+	Buckets  []WaitBucket  // 等待时间分布，计数是累积的
+}
+
+// 等待时间分布的一个区间
+type WaitBucket struct {
+	UpperBound time.Duration // 区间上界，最后一个区间为 0 表示无上界
+	Count      int64         // 等待时间不超过上界的次数
+}
+
+// 单个连接的状态
+type ConnStats struct {
+	ID           uint64
+	Addr         string
+	Locality     string
+	Age          time.Duration // 连接已建立的时长
+	Idle         time.Duration // 距离最近一次引用的时长
+	Ref          int32
+	MaxRef       int32
+	State        string   // idle / active / busy / closing
+	Ready        bool     // 是否在就绪通道中
+	Connectivity string   // grpc 连接状态
+	Leases       int64    // 累计被引用的次数
+	RPC          RPCStats // 连接上的 RPC 统计
+}
+
+// 单个位置上连接的使用情况
+type LocalityStats struct {
+	Name      string
+	Local     bool  // 是否与调用方处于同一位置
+	Conns     int32 // 连接数
+	Unhealthy int32 // 不健康的连接数
+	Refs      int32 // 引用数
+	Capacity  int32 // 非关闭中连接的最大引用数之和
+}
+
+// 连接状态
+const (
+	ConnStateIdle    = "idle"
+	ConnStateActive  = "active"
+	ConnStateBusy    = "busy"
+	ConnStateClosing = "closing"
+)
+
+// 描述信息
+func (s ConnStats) describe() string {
+	return fmt.Sprintf("id: %v, addr: %v, locality: %v, age: %v, ref: %v, state: %v, readying: %v, connectivity: %v, leases: %v, rpcs: %v, failures: %v, mean: %v, max: %v",
+		s.ID, s.Addr, s.Locality, s.Age.Truncate(time.Millisecond), s.Ref, s.State, s.Ready, s.Connectivity,
+		s.Leases, s.RPC.Total, s.RPC.Failures, s.RPC.Mean, s.RPC.Max)
+}
+
+// 描述信息
+func (s LocalityStats) describe() string {
+	return fmt.Sprintf("%s: local: %v, conns: %d, unhealthy: %d, refs: %d, capacity: %d", s.Name, s.Local, s.Conns, s.Unhealthy, s.Refs, s.Capacity)
+}
@@ -0,0 +1,15 @@
+package gogrpcpool
+
+import "context"
+
+// 链路追踪
+// 1. TraceAcquire 在开始取连接时调用，返回的 context 会传递给取连接过程中触发的拨号，返回的函数在取连接结束时调用
+// 2. TraceDial 在拨号前调用，ctx 为触发拨号的取连接的 context，后台拨号时为 context.Background()，返回的函数在拨号结束时调用
+// 3. TraceLease 在 AcquireLease 成功取得引用后调用，ctx 为调用方传入的 context，返回的函数在 Lease.Release 时调用，
+// 连接池关闭时仍未归还的引用在连接被强制关闭时调用；直接使用 Acquire、Release 时不会调用
+// 4. pool 为连接池名称，即 Options.Name
+type Tracer interface {
+	TraceAcquire(ctx context.Context, pool string) (context.Context, func(conn *Conn, err error))
+	TraceDial(ctx context.Context, pool string, addr string) func(conn *Conn, err error)
+	TraceLease(ctx context.Context, pool string, conn *Conn) func()
+}
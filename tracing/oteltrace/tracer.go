@@ -0,0 +1,134 @@
+// 连接池的 OpenTelemetry 链路追踪
+//
+// Tracer 实现了 gogrpcpool.Tracer，设置到连接池的 Options.Tracer 中后：
+//   - 每次取连接创建 grpcpool.acquire span，记录等待时间、取得的连接编号和地址
+//   - 每次拨号创建 grpcpool.dial span，由取连接触发的拨号是 grpcpool.acquire 的子 span
+//   - 每次通过 AcquireLease 取得引用创建 grpcpool.lease span，覆盖从取得到 Lease.Release 的整个持有过程，
+//     lease span 与 acquire span 同级，都是取连接时传入的 context 中的 span 的子 span；
+//     同一连接上的多个引用各自结束自己的 lease span
+//
+// 设置 Options.Events 后不再创建 acquire、lease span，改为在当前活跃的 span 上添加事件，
+// 适用于只想在已有的 RPC span 上标注等待时间的场景
+//
+// 该包是独立的 module（github.com/biandoucheng/go-grpc-pool/tracing/oteltrace），只使用连接池的项目不会引入 OpenTelemetry SDK 依赖
+package oteltrace
+
+import (
+	"context"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/biandoucheng/go-grpc-pool"
+
+type Options struct {
+	TracerProvider trace.TracerProvider // 默认使用全局的 TracerProvider
+	Events         bool                 // 取连接时在当前活跃的 span 上添加事件，而不是创建新的 span
+}
+
+// 连接池链路追踪
+type Tracer struct {
+	opts   Options
+	tracer trace.Tracer
+}
+
+// 实例化连接池链路追踪
+func New(opts Options) *Tracer {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		opts:   opts,
+		tracer: opts.TracerProvider.Tracer(instrumentationName),
+	}
+}
+
+// 实现 gogrpcpool.Tracer
+func (t *Tracer) TraceAcquire(ctx context.Context, pool string) (context.Context, func(*gogrpcpool.Conn, error)) {
+	st := time.Now()
+
+	if t.opts.Events {
+		span := trace.SpanFromContext(ctx)
+		span.AddEvent("grpcpool.acquire.start", trace.WithAttributes(attribute.String("grpcpool.pool", pool)))
+		return ctx, func(conn *gogrpcpool.Conn, err error) {
+			attrs := append(connAttributes(conn), waitAttribute(st))
+			if err != nil {
+				attrs = append(attrs, attribute.String("error", err.Error()))
+			}
+			span.AddEvent("grpcpool.acquire.end", trace.WithAttributes(attrs...))
+		}
+	}
+
+	ctx, span := t.tracer.Start(ctx, "grpcpool.acquire", trace.WithAttributes(attribute.String("grpcpool.pool", pool)))
+	return ctx, func(conn *gogrpcpool.Conn, err error) {
+		span.SetAttributes(connAttributes(conn)...)
+		span.SetAttributes(waitAttribute(st))
+		end(span, err)
+	}
+}
+
+// 实现 gogrpcpool.Tracer
+func (t *Tracer) TraceDial(ctx context.Context, pool string, addr string) func(*gogrpcpool.Conn, error) {
+	_, span := t.tracer.Start(ctx, "grpcpool.dial", trace.WithAttributes(
+		attribute.String("grpcpool.pool", pool),
+		attribute.String("grpcpool.addr", addr),
+	))
+	return func(conn *gogrpcpool.Conn, err error) {
+		span.SetAttributes(connAttributes(conn)...)
+		end(span, err)
+	}
+}
+
+// 实现 gogrpcpool.Tracer
+func (t *Tracer) TraceLease(ctx context.Context, pool string, conn *gogrpcpool.Conn) func() {
+	st := time.Now()
+	attrs := append(connAttributes(conn), attribute.String("grpcpool.pool", pool))
+
+	if t.opts.Events {
+		span := trace.SpanFromContext(ctx)
+		return func() {
+			span.AddEvent("grpcpool.release", trace.WithAttributes(append(attrs, holdAttribute(st))...))
+		}
+	}
+
+	_, span := t.tracer.Start(ctx, "grpcpool.lease", trace.WithAttributes(attrs...))
+	return func() {
+		span.End()
+	}
+}
+
+// 连接相关的属性
+func connAttributes(conn *gogrpcpool.Conn) []attribute.KeyValue {
+	if conn == nil {
+		return []attribute.KeyValue{}
+	}
+	return []attribute.KeyValue{
+		attribute.Int64("grpcpool.conn.id", int64(conn.ID())),
+		attribute.String("grpcpool.addr", conn.Endpoint().Addr),
+	}
+}
+
+// 等待时间属性
+func waitAttribute(st time.Time) attribute.KeyValue {
+	return attribute.Float64("grpcpool.wait_ms", float64(time.Since(st).Microseconds())/1000)
+}
+
+// 持有时间属性
+func holdAttribute(st time.Time) attribute.KeyValue {
+	return attribute.Float64("grpcpool.hold_ms", float64(time.Since(st).Microseconds())/1000)
+}
+
+// 结束 span，失败时记录错误
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
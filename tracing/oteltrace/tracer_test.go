@@ -0,0 +1,177 @@
+package oteltrace
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	gogrpcpool "github.com/biandoucheng/go-grpc-pool"
+	"github.com/biandoucheng/go-grpc-pool/internal/pooltest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// 使用 SpanRecorder 追踪的连接池
+func tracedPool(t *testing.T, opts Options) (*gogrpcpool.Pool, *tracetest.SpanRecorder, *sdktrace.TracerProvider) {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	opts.TracerProvider = provider
+	pool, err := gogrpcpool.New(pooltest.Serve(t),
+		gogrpcpool.WithName("greeter"),
+		gogrpcpool.WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		gogrpcpool.WithMaxConns(1),
+		gogrpcpool.WithMaxIdleConns(1),
+		gogrpcpool.WithMaxRefs(2),
+		gogrpcpool.WithTracer(New(opts)),
+		gogrpcpool.WithLogger(pooltest.NopLogger{}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.Run()
+	return pool, recorder, provider
+}
+
+// 已结束的指定名称的 span
+func ended(recorder *tracetest.SpanRecorder, name string) []sdktrace.ReadOnlySpan {
+	spans := []sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestLeaseSpan(t *testing.T) {
+	pool, recorder, provider := tracedPool(t, Options{})
+	defer pool.Close(context.Background())
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "rpc")
+
+	lease, err := pool.AcquireLease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(ended(recorder, "grpcpool.lease")); n != 0 {
+		t.Fatalf("lease span ended before Release")
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	lease.Release()
+	parent.End()
+
+	leases := ended(recorder, "grpcpool.lease")
+	if len(leases) != 1 {
+		t.Fatalf("got %d lease spans, want 1", len(leases))
+	}
+	span := leases[0]
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatal("lease span is not a child of the caller span")
+	}
+	if d := span.EndTime().Sub(span.StartTime()); d < 5*time.Millisecond {
+		t.Fatalf("lease span lasted %v, want >= 5ms", d)
+	}
+
+	acquires := ended(recorder, "grpcpool.acquire")
+	if len(acquires) != 1 || acquires[0].Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("got %d acquire spans, want 1 child of the caller span", len(acquires))
+	}
+}
+
+func TestLeaseSpanSharedConn(t *testing.T) {
+	pool, recorder, provider := tracedPool(t, Options{})
+	defer pool.Close(context.Background())
+
+	tracer := provider.Tracer("test")
+	ctxA, parentA := tracer.Start(context.Background(), "a")
+	ctxB, parentB := tracer.Start(context.Background(), "b")
+
+	// 连接池只有一个连接，两个引用共享这个连接
+	a, err := pool.AcquireLease(ctxA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := pool.AcquireLease(ctxB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Conn() != b.Conn() {
+		t.Fatal("leases are not on the same conn")
+	}
+
+	// 先归还后取得的引用，只结束它自己的 lease span
+	b.Release()
+	leases := ended(recorder, "grpcpool.lease")
+	if len(leases) != 1 || leases[0].Parent().SpanID() != parentB.SpanContext().SpanID() {
+		t.Fatalf("got %d lease spans after releasing b, want 1 child of b", len(leases))
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	a.Release()
+	leases = ended(recorder, "grpcpool.lease")
+	if len(leases) != 2 || leases[1].Parent().SpanID() != parentA.SpanContext().SpanID() {
+		t.Fatalf("got %d lease spans after releasing a, want the second a child of a", len(leases))
+	}
+	if leases[1].EndTime().Sub(leases[1].StartTime()) <= leases[0].EndTime().Sub(leases[0].StartTime()) {
+		t.Fatal("lease a should be held longer than lease b")
+	}
+	parentA.End()
+	parentB.End()
+}
+
+func TestLeaseSpanAbandoned(t *testing.T) {
+	pool, recorder, _ := tracedPool(t, Options{})
+
+	for i := 0; i < 2; i++ {
+		if _, err := pool.AcquireLease(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 强制关闭时结束所有未归还的引用
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var ce *gogrpcpool.CloseError
+	if err := pool.Close(ctx); !errors.As(err, &ce) || ce.Abandoned != 2 {
+		t.Fatalf("Close = %v, want 2 abandoned leases", err)
+	}
+	if n := len(ended(recorder, "grpcpool.lease")); n != 2 {
+		t.Fatalf("got %d lease spans, want 2", n)
+	}
+}
+
+func TestLeaseEvents(t *testing.T) {
+	pool, recorder, provider := tracedPool(t, Options{Events: true})
+	defer pool.Close(context.Background())
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "rpc")
+	lease, err := pool.AcquireLease(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lease.Release()
+	parent.End()
+
+	if n := len(ended(recorder, "grpcpool.lease")); n != 0 {
+		t.Fatalf("got %d lease spans in events mode, want 0", n)
+	}
+
+	events := map[string]bool{}
+	for _, e := range ended(recorder, "rpc")[0].Events() {
+		events[e.Name] = true
+	}
+	for _, name := range []string{"grpcpool.acquire.start", "grpcpool.acquire.end", "grpcpool.release"} {
+		if !events[name] {
+			t.Errorf("missing event %s in %v", name, events)
+		}
+	}
+}